@@ -0,0 +1,131 @@
+package analytics
+
+import (
+	"github.com/clambin/solaredge/v2"
+	"slices"
+	"time"
+)
+
+// BatteryHealth summarizes the state and usage of a battery over a period, as reported by solaredge.Client.GetStorageData.
+// Energy is reported in Wh.
+type BatteryHealth struct {
+	Start        time.Time
+	End          time.Time
+	SerialNumber string
+	ModelNumber  string
+	// Nameplate is the battery's rated capacity.
+	Nameplate float64
+	// FullPackEnergyAvailable is the maximum energy the battery could hold at the end of the period.
+	FullPackEnergyAvailable float64
+	// Charged and Discharged contain the energy charged into, and discharged from, the battery during the period.
+	Charged    float64
+	Discharged float64
+	// GridCharged contains the energy charged into the battery from the grid during the period.
+	GridCharged float64
+}
+
+// StateOfHealth returns the capacity of the battery at the end of the period, relative to its nameplate capacity.
+// Returns false if the battery's nameplate or current capacity is unknown.
+func (h BatteryHealth) StateOfHealth() (float64, bool) {
+	if h.Nameplate == 0 || h.FullPackEnergyAvailable == 0 {
+		return 0, false
+	}
+	return h.FullPackEnergyAvailable / h.Nameplate, true
+}
+
+// EquivalentFullCycles returns the number of full charge/discharge cycles that corresponds to the energy discharged
+// during the period. Returns false if the battery's nameplate capacity is unknown.
+func (h BatteryHealth) EquivalentFullCycles() (float64, bool) {
+	if h.Nameplate == 0 {
+		return 0, false
+	}
+	return h.Discharged / h.Nameplate, true
+}
+
+// RoundTripEfficiency returns the energy discharged during the period, relative to the energy charged.
+// Returns false if no energy was charged during the period.
+//
+// Note: the result is only meaningful over longer periods, as the battery's state of charge at the start and end of
+// the period is not taken into account.
+func (h BatteryHealth) RoundTripEfficiency() (float64, bool) {
+	if h.Charged == 0 {
+		return 0, false
+	}
+	return h.Discharged / h.Charged, true
+}
+
+// GridChargingShare returns the share of the charged energy that came from the grid.
+// Returns false if no energy was charged during the period.
+func (h BatteryHealth) GridChargingShare() (float64, bool) {
+	if h.Charged == 0 {
+		return 0, false
+	}
+	return h.GridCharged / h.Charged, true
+}
+
+// AnalyzeStorage returns the BatteryHealth of each battery in the StorageData. Batteries that reported less than two
+// telemetries are skipped, as no usage can be determined for them.
+//
+// Charged and Discharged are calculated as the difference between the first and last telemetry in the period, as the
+// API reports them as lifetime counters. The API reports ACGridCharging as the energy charged from the grid within the
+// requested time range: GridCharged is the last value reported. Telemetries that report zero (or null) for a value are
+// ignored for that value.
+func AnalyzeStorage(data solaredge.StorageData) []BatteryHealth {
+	health := make([]BatteryHealth, 0, len(data.Batteries))
+	for _, b := range data.Batteries {
+		if h, ok := AnalyzeBattery(b); ok {
+			health = append(health, h)
+		}
+	}
+	return health
+}
+
+// AnalyzeBattery returns the BatteryHealth of a battery. Returns false if the battery reported less than two telemetries.
+func AnalyzeBattery(b solaredge.Battery) (BatteryHealth, bool) {
+	if len(b.Telemetries) < 2 {
+		return BatteryHealth{}, false
+	}
+	telemetries := slices.Clone(b.Telemetries)
+	slices.SortFunc(telemetries, func(a, b solaredge.BatteryTelemetry) int {
+		return time.Time(a.TimeStamp).Compare(time.Time(b.TimeStamp))
+	})
+	first, last := telemetries[0], telemetries[len(telemetries)-1]
+
+	h := BatteryHealth{
+		Start:        time.Time(first.TimeStamp),
+		End:          time.Time(last.TimeStamp),
+		SerialNumber: b.SerialNumber,
+		ModelNumber:  b.ModelNumber,
+		Nameplate:    float64(b.Nameplate),
+	}
+	h.Charged = increase(telemetries, func(t solaredge.BatteryTelemetry) float64 { return t.LifeTimeEnergyCharged })
+	h.Discharged = increase(telemetries, func(t solaredge.BatteryTelemetry) float64 { return t.LifeTimeEnergyDischarged })
+	h.GridCharged = lastReported(telemetries, func(t solaredge.BatteryTelemetry) float64 { return t.ACGridCharging })
+	// use the last telemetry that reports the battery's capacity
+	h.FullPackEnergyAvailable = lastReported(telemetries, func(t solaredge.BatteryTelemetry) float64 { return t.FullPackEnergyAvailable })
+	return h, true
+}
+
+// increase returns the difference between the first and last non-zero value of a counter.
+func increase(telemetries []solaredge.BatteryTelemetry, value func(solaredge.BatteryTelemetry) float64) float64 {
+	var first, last float64
+	for _, t := range telemetries {
+		if v := value(t); v != 0 {
+			if first == 0 {
+				first = v
+			}
+			last = v
+		}
+	}
+	return last - first
+}
+
+// lastReported returns the last non-zero value.
+func lastReported(telemetries []solaredge.BatteryTelemetry, value func(solaredge.BatteryTelemetry) float64) float64 {
+	for i := len(telemetries) - 1; i >= 0; i-- {
+		if v := value(telemetries[i]); v != 0 {
+			return v
+		}
+	}
+	return 0
+}
@@ -0,0 +1,78 @@
+package analytics
+
+import (
+	"github.com/clambin/solaredge/v2"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeStorage(t *testing.T) {
+	ts := func(day int) solaredge.Time {
+		return solaredge.Time(time.Date(2024, time.June, day, 0, 0, 0, 0, time.UTC))
+	}
+	data := solaredge.StorageData{
+		BatteryCount: 2,
+		Batteries: []solaredge.Battery{
+			{
+				SerialNumber: "SN1",
+				Nameplate:    10000,
+				Telemetries: []solaredge.BatteryTelemetry{
+					{TimeStamp: ts(7), LifeTimeEnergyCharged: 150000, LifeTimeEnergyDischarged: 135000, ACGridCharging: 5000, FullPackEnergyAvailable: 0},
+					{TimeStamp: ts(1), LifeTimeEnergyCharged: 100000, LifeTimeEnergyDischarged: 90000, ACGridCharging: 0, FullPackEnergyAvailable: 9600},
+					{TimeStamp: ts(4), LifeTimeEnergyCharged: 120000, LifeTimeEnergyDischarged: 110000, ACGridCharging: 2000, FullPackEnergyAvailable: 9500},
+					// a telemetry without values is ignored
+					{TimeStamp: ts(8)},
+				},
+			},
+			{
+				SerialNumber: "SN2",
+				Telemetries:  []solaredge.BatteryTelemetry{{TimeStamp: ts(1)}},
+			},
+		},
+	}
+
+	health := AnalyzeStorage(data)
+	if len(health) != 1 {
+		t.Fatalf("got %d batteries, want 1", len(health))
+	}
+	h := health[0]
+	if h.SerialNumber != "SN1" || !h.Start.Equal(time.Time(ts(1))) || !h.End.Equal(time.Time(ts(8))) {
+		t.Errorf("unexpected battery health: %+v", h)
+	}
+	if h.Charged != 50000 || h.Discharged != 45000 || h.GridCharged != 5000 {
+		t.Errorf("unexpected energy: %+v", h)
+	}
+
+	tests := []struct {
+		name string
+		f    func() (float64, bool)
+		want float64
+	}{
+		{name: "StateOfHealth", f: h.StateOfHealth, want: 0.95},
+		{name: "EquivalentFullCycles", f: h.EquivalentFullCycles, want: 4.5},
+		{name: "RoundTripEfficiency", f: h.RoundTripEfficiency, want: 0.9},
+		{name: "GridChargingShare", f: h.GridChargingShare, want: 0.1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.f()
+			if !ok || got != tt.want {
+				t.Errorf("got %v (%v), want %v", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatteryHealth_Unknown(t *testing.T) {
+	var h BatteryHealth
+	for name, f := range map[string]func() (float64, bool){
+		"StateOfHealth":        h.StateOfHealth,
+		"EquivalentFullCycles": h.EquivalentFullCycles,
+		"RoundTripEfficiency":  h.RoundTripEfficiency,
+		"GridChargingShare":    h.GridChargingShare,
+	} {
+		if _, ok := f(); ok {
+			t.Errorf("%s: expected no result", name)
+		}
+	}
+}
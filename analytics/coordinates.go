@@ -0,0 +1,87 @@
+package analytics
+
+import (
+	_ "embed"
+	"github.com/clambin/solaredge/v2"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// zone.tab is the list of time zones of the IANA time zone database, with the coordinates of each zone's principal
+// city. The file is in the public domain.
+//
+//go:embed zone.tab
+var zoneTab string
+
+// SiteCoordinates returns the approximate coordinates of the site: those of the principal city of the site's time
+// zone (e.g. Brussels for Europe/Brussels), as listed in the IANA time zone database. This is accurate enough for
+// a YieldModel in most countries, but may be several hundred kilometres off in large time zones.
+// Returns false if the time zone isn't listed.
+func SiteCoordinates(details solaredge.SiteDetails) (Coordinates, bool) {
+	c, ok := zoneCoordinates()[details.Location.TimeZone]
+	return c, ok
+}
+
+var zoneCoordinates = sync.OnceValue(func() map[string]Coordinates {
+	zones := make(map[string]Coordinates)
+	for _, line := range strings.Split(zoneTab, "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		if c, ok := parseISO6709(fields[1]); ok {
+			zones[fields[2]] = c
+		}
+	}
+	return zones
+})
+
+// parseISO6709 parses coordinates in the format used by zone.tab: ±DDMM±DDDMM or ±DDMMSS±DDDMMSS.
+func parseISO6709(s string) (Coordinates, bool) {
+	i := strings.IndexAny(s[min(1, len(s)):], "+-") + 1
+	if i <= 0 {
+		return Coordinates{}, false
+	}
+	latitude, ok := parseDegrees(s[:i], 2)
+	if !ok {
+		return Coordinates{}, false
+	}
+	longitude, ok := parseDegrees(s[i:], 3)
+	if !ok {
+		return Coordinates{}, false
+	}
+	return Coordinates{Latitude: latitude, Longitude: longitude}, true
+}
+
+// parseDegrees parses a signed angle of the form ±DDMM or ±DDMMSS, with the given number of digits for the degrees.
+func parseDegrees(s string, degreeDigits int) (float64, bool) {
+	if len(s) != 1+degreeDigits+2 && len(s) != 1+degreeDigits+4 {
+		return 0, false
+	}
+	sign := 1.0
+	switch s[0] {
+	case '-':
+		sign = -1
+	case '+':
+	default:
+		return 0, false
+	}
+	var value float64
+	for i, scale := 1, 1.0; i < len(s); scale *= 60 {
+		n := degreeDigits
+		if i > 1 {
+			n = 2
+		}
+		part, err := strconv.Atoi(s[i : i+n])
+		if err != nil {
+			return 0, false
+		}
+		value += float64(part) / scale
+		i += n
+	}
+	return sign * value, true
+}
@@ -0,0 +1,39 @@
+package analytics
+
+import (
+	"github.com/clambin/solaredge/v2"
+	"math"
+	"testing"
+)
+
+func TestSiteCoordinates(t *testing.T) {
+	tests := []struct {
+		timeZone string
+		want     Coordinates
+		ok       bool
+	}{
+		{timeZone: "Europe/Brussels", want: Coordinates{Latitude: 50 + 50.0/60, Longitude: 4 + 20.0/60}, ok: true},
+		{timeZone: "America/New_York", want: Coordinates{Latitude: 40 + 42.0/60 + 51.0/3600, Longitude: -(74 + 0.0/60 + 23.0/3600)}, ok: true},
+		{timeZone: "Australia/Sydney", want: Coordinates{Latitude: -(33 + 52.0/60), Longitude: 151 + 13.0/60}, ok: true},
+		{timeZone: "UTC"},
+		{timeZone: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.timeZone, func(t *testing.T) {
+			var details solaredge.SiteDetails
+			details.Location.TimeZone = tt.timeZone
+			got, ok := SiteCoordinates(details)
+			if ok != tt.ok || math.Abs(got.Latitude-tt.want.Latitude) > 1e-9 || math.Abs(got.Longitude-tt.want.Longitude) > 1e-9 {
+				t.Errorf("SiteCoordinates() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func Test_parseISO6709(t *testing.T) {
+	for _, s := range []string{"", "+", "+5050", "+5050+0042", "5050+00420", "+50x0+00420", "+5050+004200"} {
+		if c, ok := parseISO6709(s); ok {
+			t.Errorf("parseISO6709(%q) = %v, want an error", s, c)
+		}
+	}
+}
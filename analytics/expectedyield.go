@@ -0,0 +1,160 @@
+package analytics
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"github.com/clambin/solaredge/v2"
+	"math"
+	"time"
+)
+
+// Coordinates of a site, in degrees. North and East are positive.
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// SunElevation returns the elevation of the sun (in degrees above the horizon) at the given coordinates and time.
+// The calculation uses the approximation published by the U.S. Naval Observatory, which is accurate to within a fraction
+// of a degree for the coming decades.
+func SunElevation(c Coordinates, t time.Time) float64 {
+	const rad = math.Pi / 180
+
+	// days since J2000.0
+	n := float64(t.UnixNano())/float64(24*time.Hour) + 2440587.5 - 2451545.0
+
+	meanLongitude := math.Mod(280.460+0.9856474*n, 360)
+	meanAnomaly := math.Mod(357.528+0.9856003*n, 360) * rad
+	eclipticLongitude := (meanLongitude + 1.915*math.Sin(meanAnomaly) + 0.020*math.Sin(2*meanAnomaly)) * rad
+	obliquity := (23.439 - 0.0000004*n) * rad
+
+	rightAscension := math.Atan2(math.Cos(obliquity)*math.Sin(eclipticLongitude), math.Cos(eclipticLongitude))
+	declination := math.Asin(math.Sin(obliquity) * math.Sin(eclipticLongitude))
+
+	siderealTime := math.Mod(18.697374558+24.06570982441908*n, 24) * 15 * rad
+	hourAngle := siderealTime + c.Longitude*rad - rightAscension
+
+	latitude := c.Latitude * rad
+	elevation := math.Asin(math.Sin(latitude)*math.Sin(declination) + math.Cos(latitude)*math.Cos(declination)*math.Cos(hourAngle))
+	return elevation / rad
+}
+
+// clearSkyIrradiance returns the global horizontal irradiance (in W/m²) under a clear sky, for a given sun elevation,
+// using the Haurwitz model.
+func clearSkyIrradiance(elevation float64) float64 {
+	cosZenith := math.Sin(elevation * math.Pi / 180)
+	if cosZenith <= 0 {
+		return 0
+	}
+	return 1098 * cosZenith * math.Exp(-0.057/cosZenith)
+}
+
+// DefaultPerformanceFactor is the share of the clear-sky production that a typical installation achieves, after losses
+// in the modules, cabling and inverter.
+const DefaultPerformanceFactor = 0.85
+
+// A YieldModel estimates the power a site should produce under a clear sky.
+//
+// The model is intentionally simple: it assumes horizontal modules and ignores shading, weather and temperature.
+// It provides a baseline to compare the site's actual production against, not an accurate forecast.
+type YieldModel struct {
+	// Location of the site. The API does not report a site's coordinates: configure them, or let NewYieldModel derive
+	// them from the site's time zone (see SiteCoordinates).
+	Coordinates Coordinates
+	// PeakPower of the site, in kWp.
+	PeakPower float64
+	// PerformanceFactor is the share of the clear-sky production the installation achieves. Zero selects
+	// DefaultPerformanceFactor: an installation that produces nothing has no use for a yield model.
+	PerformanceFactor float64
+	// TimeZone of the site. Used to interpret the timestamps reported by the API. Defaults to UTC.
+	TimeZone *time.Location
+}
+
+// ErrNoCoordinates is returned by NewYieldModel if no coordinates are configured and they can't be derived from the
+// site's time zone.
+var ErrNoCoordinates = errors.New("no coordinates for the site")
+
+// NewYieldModel returns a YieldModel for the site. The peak power and time zone are taken from the site details.
+// If coordinates is the zero value, the coordinates are derived from the site's time zone (see SiteCoordinates).
+func NewYieldModel(details solaredge.SiteDetails, coordinates Coordinates) (YieldModel, error) {
+	loc, err := time.LoadLocation(details.Location.TimeZone)
+	if err != nil {
+		return YieldModel{}, fmt.Errorf("time zone: %w", err)
+	}
+	if coordinates == (Coordinates{}) {
+		var ok bool
+		if coordinates, ok = SiteCoordinates(details); !ok {
+			return YieldModel{}, fmt.Errorf("%w: time zone %q", ErrNoCoordinates, details.Location.TimeZone)
+		}
+	}
+	return YieldModel{
+		Coordinates: coordinates,
+		PeakPower:   details.PeakPower,
+		TimeZone:    loc,
+	}, nil
+}
+
+// ExpectedPower returns the power (in W) the site should produce at time t under a clear sky.
+func (m YieldModel) ExpectedPower(t time.Time) float64 {
+	irradiance := clearSkyIrradiance(SunElevation(m.Coordinates, t))
+	// peak power is rated at an irradiance of 1000 W/m²
+	return m.PeakPower * irradiance * cmp.Or(m.PerformanceFactor, DefaultPerformanceFactor)
+}
+
+// ExpectedPowerCurve returns the expected power (in W) for each interval between start and end. Like the values
+// returned by solaredge.Client.GetPowerMeasurements, each value holds the average power during the interval starting
+// at the value's timestamp, and timestamps are expressed in the site's local time.
+func (m YieldModel) ExpectedPowerCurve(start, end time.Time, interval time.Duration) []solaredge.Value {
+	loc := m.timeZone()
+	var values []solaredge.Value
+	for ts := start.In(loc); ts.Before(end); ts = ts.Add(interval) {
+		values = append(values, solaredge.Value{Date: wallClock(ts), Value: m.ExpectedPower(ts.Add(interval / 2))})
+	}
+	return values
+}
+
+// DailyPerformance compares the energy produced by a site on a given day with the energy expected under a clear sky.
+type DailyPerformance struct {
+	Day time.Time
+	// Measured and Expected energy, in Wh.
+	Measured float64
+	Expected float64
+}
+
+// PerformanceRatio returns the measured energy, relative to the expected energy. Returns false if no energy was expected.
+func (p DailyPerformance) PerformanceRatio() (float64, bool) {
+	if p.Expected == 0 {
+		return 0, false
+	}
+	return p.Measured / p.Expected, true
+}
+
+// Performance compares the measured power values (in W), as returned by solaredge.Client.GetPowerMeasurements, with
+// the expected power under a clear sky and returns the result for each day.
+func (m YieldModel) Performance(measured []solaredge.Value, interval time.Duration) []DailyPerformance {
+	loc := m.timeZone()
+	expected := make([]solaredge.Value, len(measured))
+	for i, v := range measured {
+		expected[i] = solaredge.Value{Date: v.Date, Value: m.ExpectedPower(v.Date.Local(loc).Add(interval / 2))}
+	}
+	measuredDays := Integrate(measured, interval, solaredge.TimeUnitDay, loc)
+	expectedDays := Integrate(expected, interval, solaredge.TimeUnitDay, loc)
+
+	performance := make([]DailyPerformance, len(measuredDays))
+	for i := range measuredDays {
+		performance[i] = DailyPerformance{
+			Day:      measuredDays[i].Start,
+			Measured: measuredDays[i].Value,
+			Expected: expectedDays[i].Value,
+		}
+	}
+	return performance
+}
+
+func (m YieldModel) timeZone() *time.Location {
+	if m.TimeZone == nil {
+		return time.UTC
+	}
+	return m.TimeZone
+}
@@ -0,0 +1,115 @@
+package analytics
+
+import (
+	"errors"
+	"github.com/clambin/solaredge/v2"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSunElevation(t *testing.T) {
+	brussels := Coordinates{Latitude: 50.85, Longitude: 4.35}
+	tests := []struct {
+		name        string
+		coordinates Coordinates
+		t           time.Time
+		want        float64
+	}{
+		{name: "equinox noon at equator", coordinates: Coordinates{}, t: time.Date(2024, time.March, 20, 12, 7, 0, 0, time.UTC), want: 90},
+		{name: "summer solstice noon", coordinates: brussels, t: time.Date(2024, time.June, 20, 11, 45, 0, 0, time.UTC), want: 62.6},
+		{name: "winter solstice noon", coordinates: brussels, t: time.Date(2024, time.December, 21, 11, 40, 0, 0, time.UTC), want: 15.7},
+		{name: "midnight", coordinates: brussels, t: time.Date(2024, time.June, 20, 23, 45, 0, 0, time.UTC), want: -15.8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SunElevation(tt.coordinates, tt.t); math.Abs(got-tt.want) > 0.5 {
+				t.Errorf("SunElevation() = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewYieldModel(t *testing.T) {
+	var details solaredge.SiteDetails
+	details.PeakPower = 5
+	details.Location.TimeZone = "Europe/Brussels"
+	m, err := NewYieldModel(details, Coordinates{Latitude: 50.85, Longitude: 4.35})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.PeakPower != 5 || m.TimeZone.String() != "Europe/Brussels" {
+		t.Errorf("unexpected model: %+v", m)
+	}
+
+	// without coordinates, the model uses the coordinates of the time zone's principal city
+	if m, err = NewYieldModel(details, Coordinates{}); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(m.Coordinates.Latitude-50.83) > 0.01 || math.Abs(m.Coordinates.Longitude-4.33) > 0.01 {
+		t.Errorf("unexpected coordinates: %+v", m.Coordinates)
+	}
+
+	details.Location.TimeZone = "UTC"
+	if _, err = NewYieldModel(details, Coordinates{}); !errors.Is(err, ErrNoCoordinates) {
+		t.Errorf("expected ErrNoCoordinates, got %v", err)
+	}
+
+	details.Location.TimeZone = "invalid"
+	if _, err = NewYieldModel(details, Coordinates{}); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestYieldModel_Performance(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Brussels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := YieldModel{Coordinates: Coordinates{Latitude: 50.85, Longitude: 4.35}, PeakPower: 5, TimeZone: loc}
+
+	start := time.Date(2024, time.June, 20, 0, 0, 0, 0, loc)
+	curve := m.ExpectedPowerCurve(start, start.AddDate(0, 0, 2), 15*time.Minute)
+	if len(curve) != 2*96 {
+		t.Fatalf("got %d values, want %d", len(curve), 2*96)
+	}
+	// the sun is highest around 13:45 local time (11:45 UTC)
+	var peak solaredge.Value
+	for _, v := range curve[:96] {
+		if v.Value > peak.Value {
+			peak = v
+		}
+	}
+	if hour := time.Time(peak.Date).Hour(); hour != 13 {
+		t.Errorf("expected peak at 13:xx local time, got %v", time.Time(peak.Date))
+	}
+	if peak.Value <= 0 || peak.Value > 5000 {
+		t.Errorf("unexpected peak power: %v", peak.Value)
+	}
+
+	// the site produced 80% of the expected power on the first day, and 40% on the second
+	measured := make([]solaredge.Value, len(curve))
+	for i, v := range curve {
+		measured[i] = solaredge.Value{Date: v.Date, Value: 0.8 * v.Value}
+		if i >= 96 {
+			measured[i].Value = 0.4 * v.Value
+		}
+	}
+	performance := m.Performance(measured, 15*time.Minute)
+	if len(performance) != 2 {
+		t.Fatalf("got %d days, want 2", len(performance))
+	}
+	for i, want := range []float64{0.8, 0.4} {
+		got, ok := performance[i].PerformanceRatio()
+		if !ok || math.Abs(got-want) > 0.001 {
+			t.Errorf("day %d: got %v (%v), want %v", i, got, ok, want)
+		}
+	}
+	if !performance[0].Day.Equal(start) {
+		t.Errorf("got day %v, want %v", performance[0].Day, start)
+	}
+
+	if _, ok := (DailyPerformance{}).PerformanceRatio(); ok {
+		t.Error("expected no performance ratio")
+	}
+}
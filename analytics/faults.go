@@ -0,0 +1,195 @@
+package analytics
+
+import (
+	"fmt"
+	"github.com/clambin/solaredge/v2"
+	"strings"
+	"time"
+)
+
+// Severity indicates how serious a Finding is.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// FindingType identifies the kind of problem detected in an inverter's telemetry.
+type FindingType string
+
+const (
+	FindingFaultMode       FindingType = "fault_mode"
+	FindingGroundFault     FindingType = "ground_fault"
+	FindingOverTemperature FindingType = "over_temperature"
+	FindingFrequency       FindingType = "frequency_excursion"
+	FindingVoltage         FindingType = "voltage_excursion"
+	FindingThrottling      FindingType = "throttling"
+)
+
+// A Finding is a problem detected in an inverter's telemetry.
+type Finding struct {
+	Time         time.Time
+	SerialNumber string
+	Type         FindingType
+	Severity     Severity
+	// Value is the measurement that triggered the finding. Zero for FindingFaultMode.
+	Value   float64
+	Message string
+}
+
+// Thresholds determine when an inverter's telemetry is considered abnormal. A zero threshold disables the check.
+type Thresholds struct {
+	// MinGroundFaultResistance is the lowest acceptable isolation resistance (in kΩ).
+	MinGroundFaultResistance float64
+	// WarningTemperature and CriticalTemperature are the temperatures (in ºC) above which a warning or critical
+	// finding is reported.
+	WarningTemperature  float64
+	CriticalTemperature float64
+	// MinFrequency and MaxFrequency are the acceptable range of the AC frequency (in Hz).
+	MinFrequency float64
+	MaxFrequency float64
+	// MinVoltage and MaxVoltage are the acceptable range of the AC voltage (in V).
+	MinVoltage float64
+	MaxVoltage float64
+	// MinPowerLimit is the lowest power limit (as a percentage of nominal power) before the inverter is considered throttled.
+	MinPowerLimit float64
+}
+
+// DefaultThresholds are suitable for inverters connected to a 230V / 50Hz grid.
+var DefaultThresholds = Thresholds{
+	MinGroundFaultResistance: 600,
+	WarningTemperature:       75,
+	CriticalTemperature:      85,
+	MinFrequency:             49,
+	MaxFrequency:             51,
+	MinVoltage:               207,
+	MaxVoltage:               253,
+	MinPowerLimit:            100,
+}
+
+// A FaultDetector scans an inverter's telemetry, as returned by solaredge.Client.GetInverterTechnicalData, for problems.
+type FaultDetector struct {
+	// Thresholds per inverter model. Models that are not listed use the default thresholds.
+	Models map[string]Thresholds
+	// Default thresholds. If not set, DefaultThresholds is used.
+	Default *Thresholds
+}
+
+func (d FaultDetector) thresholds(model string) Thresholds {
+	if t, ok := d.Models[model]; ok {
+		return t
+	}
+	if d.Default != nil {
+		return *d.Default
+	}
+	return DefaultThresholds
+}
+
+// Scan returns all findings in the telemetry of the inverter, in chronological order of the telemetry.
+func (d FaultDetector) Scan(inverter solaredge.Inverter, telemetries []solaredge.InverterTelemetry) []Finding {
+	thresholds := d.thresholds(inverter.Model)
+	var findings []Finding
+	for _, telemetry := range telemetries {
+		for _, f := range thresholds.check(telemetry) {
+			f.Time = time.Time(telemetry.Time)
+			f.SerialNumber = inverter.SerialNumber
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func (t Thresholds) check(telemetry solaredge.InverterTelemetry) []Finding {
+	var findings []Finding
+
+	if severity, ok := modeSeverity(telemetry.InverterMode); ok {
+		findings = append(findings, Finding{
+			Type:     FindingFaultMode,
+			Severity: severity,
+			Message:  "inverter mode is " + telemetry.InverterMode,
+		})
+	}
+	// a ground fault resistance of zero means it was not reported
+	if t.MinGroundFaultResistance > 0 && telemetry.GroundFaultResistance > 0 && telemetry.GroundFaultResistance < t.MinGroundFaultResistance {
+		findings = append(findings, Finding{
+			Type:     FindingGroundFault,
+			Severity: SeverityCritical,
+			Value:    telemetry.GroundFaultResistance,
+			Message:  fmt.Sprintf("ground fault resistance %.0f kΩ below %.0f kΩ", telemetry.GroundFaultResistance, t.MinGroundFaultResistance),
+		})
+	}
+	switch {
+	case t.CriticalTemperature > 0 && telemetry.Temperature > t.CriticalTemperature:
+		findings = append(findings, Finding{
+			Type:     FindingOverTemperature,
+			Severity: SeverityCritical,
+			Value:    telemetry.Temperature,
+			Message:  fmt.Sprintf("temperature %.1f ºC above %.1f ºC", telemetry.Temperature, t.CriticalTemperature),
+		})
+	case t.WarningTemperature > 0 && telemetry.Temperature > t.WarningTemperature:
+		findings = append(findings, Finding{
+			Type:     FindingOverTemperature,
+			Severity: SeverityWarning,
+			Value:    telemetry.Temperature,
+			Message:  fmt.Sprintf("temperature %.1f ºC above %.1f ºC", telemetry.Temperature, t.WarningTemperature),
+		})
+	}
+	// AC readings are zero when the inverter is not connected to the grid (e.g. at night)
+	if f := telemetry.L1Data.AcFrequency; f > 0 && outOfRange(f, t.MinFrequency, t.MaxFrequency) {
+		findings = append(findings, Finding{
+			Type:     FindingFrequency,
+			Severity: SeverityWarning,
+			Value:    f,
+			Message:  fmt.Sprintf("AC frequency %.2f Hz outside [%.2f, %.2f] Hz", f, t.MinFrequency, t.MaxFrequency),
+		})
+	}
+	if v := telemetry.L1Data.AcVoltage; v > 0 && outOfRange(v, t.MinVoltage, t.MaxVoltage) {
+		findings = append(findings, Finding{
+			Type:     FindingVoltage,
+			Severity: SeverityWarning,
+			Value:    v,
+			Message:  fmt.Sprintf("AC voltage %.1f V outside [%.1f, %.1f] V", v, t.MinVoltage, t.MaxVoltage),
+		})
+	}
+	if telemetry.InverterMode == "THROTTLED" || (t.MinPowerLimit > 0 && telemetry.PowerLimit > 0 && telemetry.PowerLimit < t.MinPowerLimit) {
+		findings = append(findings, Finding{
+			Type:     FindingThrottling,
+			Severity: SeverityInfo,
+			Value:    telemetry.PowerLimit,
+			Message:  fmt.Sprintf("power limited to %.0f%%", telemetry.PowerLimit),
+		})
+	}
+	return findings
+}
+
+func outOfRange(value, lower, upper float64) bool {
+	return (lower > 0 && value < lower) || (upper > 0 && value > upper)
+}
+
+// modeSeverity returns the severity of an inverter mode, as reported in InverterTelemetry.InverterMode.
+// Returns false if the mode does not indicate a problem.
+func modeSeverity(mode string) (Severity, bool) {
+	switch {
+	case mode == "FAULT", mode == "LOCKED_INV_TRIP", mode == "LOCKED_INV_ARC_DETECTED":
+		return SeverityCritical, true
+	case strings.HasPrefix(mode, "LOCKED_"):
+		return SeverityWarning, true
+	default:
+		return SeverityInfo, false
+	}
+}
@@ -0,0 +1,94 @@
+package analytics
+
+import (
+	"github.com/clambin/solaredge/v2"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFaultDetector_Scan(t *testing.T) {
+	ts := solaredge.Time(time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC))
+	normal := solaredge.InverterTelemetry{
+		Time:                  ts,
+		InverterMode:          "MPPT",
+		Temperature:           40,
+		GroundFaultResistance: 5000,
+		PowerLimit:            100,
+		L1Data:                solaredge.InverterTelemetryL1Data{AcFrequency: 50, AcVoltage: 230},
+	}
+	with := func(f func(*solaredge.InverterTelemetry)) solaredge.InverterTelemetry {
+		telemetry := normal
+		f(&telemetry)
+		return telemetry
+	}
+
+	tests := []struct {
+		name      string
+		telemetry solaredge.InverterTelemetry
+		want      []FindingType
+		severity  Severity
+	}{
+		{name: "normal", telemetry: normal},
+		{name: "night", telemetry: with(func(t *solaredge.InverterTelemetry) {
+			t.InverterMode = "SLEEPING"
+			t.L1Data = solaredge.InverterTelemetryL1Data{}
+			t.GroundFaultResistance = 0
+		})},
+		{name: "fault", telemetry: with(func(t *solaredge.InverterTelemetry) { t.InverterMode = "FAULT" }), want: []FindingType{FindingFaultMode}, severity: SeverityCritical},
+		{name: "locked", telemetry: with(func(t *solaredge.InverterTelemetry) { t.InverterMode = "LOCKED_COMM_TIMEOUT" }), want: []FindingType{FindingFaultMode}, severity: SeverityWarning},
+		{name: "ground fault", telemetry: with(func(t *solaredge.InverterTelemetry) { t.GroundFaultResistance = 100 }), want: []FindingType{FindingGroundFault}, severity: SeverityCritical},
+		{name: "hot", telemetry: with(func(t *solaredge.InverterTelemetry) { t.Temperature = 80 }), want: []FindingType{FindingOverTemperature}, severity: SeverityWarning},
+		{name: "too hot", telemetry: with(func(t *solaredge.InverterTelemetry) { t.Temperature = 90 }), want: []FindingType{FindingOverTemperature}, severity: SeverityCritical},
+		{name: "frequency", telemetry: with(func(t *solaredge.InverterTelemetry) { t.L1Data.AcFrequency = 51.5 }), want: []FindingType{FindingFrequency}, severity: SeverityWarning},
+		{name: "voltage", telemetry: with(func(t *solaredge.InverterTelemetry) { t.L1Data.AcVoltage = 260 }), want: []FindingType{FindingVoltage}, severity: SeverityWarning},
+		{name: "power limit", telemetry: with(func(t *solaredge.InverterTelemetry) { t.PowerLimit = 60 }), want: []FindingType{FindingThrottling}, severity: SeverityInfo},
+		{name: "throttled", telemetry: with(func(t *solaredge.InverterTelemetry) { t.InverterMode = "THROTTLED" }), want: []FindingType{FindingThrottling}, severity: SeverityInfo},
+	}
+
+	var d FaultDetector
+	inverter := solaredge.Inverter{SerialNumber: "SN1", Model: "SE5000"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := d.Scan(inverter, []solaredge.InverterTelemetry{tt.telemetry})
+			var got []FindingType
+			for _, f := range findings {
+				got = append(got, f.Type)
+				if f.SerialNumber != "SN1" || !f.Time.Equal(time.Time(ts)) || f.Severity != tt.severity {
+					t.Errorf("unexpected finding: %+v", f)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got findings %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFaultDetector_Models(t *testing.T) {
+	us := DefaultThresholds
+	us.MinFrequency, us.MaxFrequency = 59, 61
+	us.MinVoltage, us.MaxVoltage = 211, 264
+	d := FaultDetector{Models: map[string]Thresholds{"SE7600H-US": us}}
+
+	telemetry := []solaredge.InverterTelemetry{{
+		InverterMode: "MPPT",
+		PowerLimit:   100,
+		L1Data:       solaredge.InverterTelemetryL1Data{AcFrequency: 60, AcVoltage: 240},
+	}}
+
+	if findings := d.Scan(solaredge.Inverter{Model: "SE7600H-US"}, telemetry); len(findings) != 0 {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+	if findings := d.Scan(solaredge.Inverter{Model: "SE5000"}, telemetry); len(findings) != 1 || findings[0].Type != FindingFrequency {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+}
+
+func TestSeverity_String(t *testing.T) {
+	for s, want := range map[Severity]string{SeverityInfo: "info", SeverityWarning: "warning", SeverityCritical: "critical", Severity(5): "severity(5)"} {
+		if got := s.String(); got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	}
+}
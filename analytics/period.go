@@ -0,0 +1,57 @@
+package analytics
+
+import (
+	"github.com/clambin/solaredge/v2"
+	"time"
+)
+
+// wallClock converts a time to the representation used by the API: the local wall clock time, stored as UTC.
+func wallClock(t time.Time) solaredge.Time {
+	return solaredge.Time(time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC))
+}
+
+// periodStart returns the start of the period of the given TimeUnit containing t. Periods are aligned to calendar
+// boundaries in t's location: days start at midnight, weeks on Monday, etc.
+func periodStart(t time.Time, unit solaredge.TimeUnit) time.Time {
+	// quarters and hours are aligned by subtracting the wall clock's remainder, rather than with time.Date, so the
+	// repeated hour at the end of daylight saving time isn't mapped onto its first occurrence
+	sinceHour := time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+	switch unit {
+	case solaredge.TimeUnitQuarter:
+		return t.Add(-(sinceHour % (15 * time.Minute)))
+	case solaredge.TimeUnitHour:
+		return t.Add(-sinceHour)
+	case solaredge.TimeUnitDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case solaredge.TimeUnitWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+	case solaredge.TimeUnitMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case solaredge.TimeUnitYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return t
+	}
+}
+
+// periodEnd returns the end of the period of the given TimeUnit starting at start. For days and longer periods,
+// the end is determined in start's location, so a day may last 23 or 25 hours when daylight saving time starts or ends.
+func periodEnd(start time.Time, unit solaredge.TimeUnit) time.Time {
+	switch unit {
+	case solaredge.TimeUnitQuarter:
+		return start.Add(15 * time.Minute)
+	case solaredge.TimeUnitHour:
+		return start.Add(time.Hour)
+	case solaredge.TimeUnitDay:
+		return start.AddDate(0, 0, 1)
+	case solaredge.TimeUnitWeek:
+		return start.AddDate(0, 0, 7)
+	case solaredge.TimeUnitMonth:
+		return start.AddDate(0, 1, 0)
+	case solaredge.TimeUnitYear:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start
+	}
+}
@@ -0,0 +1,52 @@
+package analytics
+
+import (
+	"github.com/clambin/solaredge/v2"
+	"testing"
+	"time"
+)
+
+func Test_periodStart(t *testing.T) {
+	ts := time.Date(2024, time.March, 14, 13, 47, 12, 0, time.UTC) // a Thursday
+	tests := []struct {
+		unit solaredge.TimeUnit
+		want time.Time
+	}{
+		{unit: solaredge.TimeUnitQuarter, want: time.Date(2024, time.March, 14, 13, 45, 0, 0, time.UTC)},
+		{unit: solaredge.TimeUnitHour, want: time.Date(2024, time.March, 14, 13, 0, 0, 0, time.UTC)},
+		{unit: solaredge.TimeUnitDay, want: time.Date(2024, time.March, 14, 0, 0, 0, 0, time.UTC)},
+		{unit: solaredge.TimeUnitWeek, want: time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC)},
+		{unit: solaredge.TimeUnitMonth, want: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)},
+		{unit: solaredge.TimeUnitYear, want: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{unit: "", want: ts},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.unit), func(t *testing.T) {
+			if got := periodStart(ts, tt.unit); !got.Equal(tt.want) {
+				t.Errorf("periodStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_periodStart_DST(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Brussels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// daylight saving time ends on 27 October 2024: the day lasts 25 hours
+	start := periodStart(time.Date(2024, time.October, 27, 13, 0, 0, 0, loc), solaredge.TimeUnitDay)
+	if end := periodEnd(start, solaredge.TimeUnitDay); !start.Equal(time.Date(2024, time.October, 27, 0, 0, 0, 0, loc)) || end.Sub(start) != 25*time.Hour {
+		t.Errorf("got %v - %v", start, end)
+	}
+
+	// the repeated hour at the end of daylight saving time is a separate period
+	start = periodStart(time.Date(2024, time.October, 27, 1, 30, 0, 0, loc), solaredge.TimeUnitHour)
+	for range 3 {
+		end := periodEnd(start, solaredge.TimeUnitHour)
+		if next := periodStart(end, solaredge.TimeUnitHour); !next.Equal(end) {
+			t.Errorf("periodStart(%v) = %v", end, next)
+		}
+		start = end
+	}
+}
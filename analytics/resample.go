@@ -0,0 +1,79 @@
+package analytics
+
+import (
+	"github.com/clambin/solaredge/v2"
+	"slices"
+	"time"
+)
+
+// Period holds the resampled value for one period of a time series.
+//
+// Start and End are aligned to calendar boundaries in the site's time zone. For days and longer periods, the duration
+// of the period follows the calendar: a day on which daylight saving time starts or ends lasts 23 or 25 hours.
+type Period struct {
+	Start time.Time
+	End   time.Time
+	Value float64
+	// Count is the number of input values that were combined into the period.
+	Count int
+}
+
+// Duration returns the length of the period.
+func (p Period) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+// Sum resamples the values to the given TimeUnit by adding up all values in each period.
+// Use this to aggregate energy values (e.g. as returned by solaredge.Client.GetEnergyDetails) to a coarser TimeUnit.
+//
+// The timestamps reported by the SolarEdge API are in the site's local time. loc is the site's time zone and is used
+// to align the periods to the site's calendar. If loc is nil, periods are aligned in UTC.
+func Sum(values []solaredge.Value, unit solaredge.TimeUnit, loc *time.Location) []Period {
+	return resample(values, unit, loc, func(v solaredge.Value) float64 { return v.Value })
+}
+
+// Average resamples the values to the given TimeUnit by averaging all values in each period.
+// Use this to determine e.g. the average power produced during each hour.
+//
+// See Sum for the meaning of loc.
+func Average(values []solaredge.Value, unit solaredge.TimeUnit, loc *time.Location) []Period {
+	periods := Sum(values, unit, loc)
+	for i := range periods {
+		periods[i].Value /= float64(periods[i].Count)
+	}
+	return periods
+}
+
+// Integrate converts power values into energy, and aggregates them to the given TimeUnit. Each value is assumed to
+// hold the average power during the interval starting at its timestamp. E.g. for the values returned by
+// solaredge.Client.GetPowerMeasurements, interval is 15 minutes. If the power is expressed in W, the returned energy
+// is in Wh.
+//
+// See Sum for the meaning of loc.
+func Integrate(values []solaredge.Value, interval time.Duration, unit solaredge.TimeUnit, loc *time.Location) []Period {
+	hours := interval.Hours()
+	return resample(values, unit, loc, func(v solaredge.Value) float64 { return v.Value * hours })
+}
+
+func resample(values []solaredge.Value, unit solaredge.TimeUnit, loc *time.Location, f func(solaredge.Value) float64) []Period {
+	if loc == nil {
+		loc = time.UTC
+	}
+	periods := make(map[time.Time]*Period)
+	for _, v := range values {
+		start := periodStart(v.Date.Local(loc), unit)
+		p, ok := periods[start]
+		if !ok {
+			p = &Period{Start: start, End: periodEnd(start, unit)}
+			periods[start] = p
+		}
+		p.Value += f(v)
+		p.Count++
+	}
+	result := make([]Period, 0, len(periods))
+	for _, p := range periods {
+		result = append(result, *p)
+	}
+	slices.SortFunc(result, func(a, b Period) int { return a.Start.Compare(b.Start) })
+	return result
+}
@@ -0,0 +1,84 @@
+package analytics
+
+import (
+	"github.com/clambin/solaredge/v2"
+	"testing"
+	"time"
+)
+
+// quarterHours returns a constant value for every quarter of an hour of the day, in the site's wall clock time.
+func quarterHours(year int, month time.Month, day int, loc *time.Location, value float64) []solaredge.Value {
+	var values []solaredge.Value
+	start := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	for ts := start; ts.Before(start.AddDate(0, 0, 1)); ts = ts.Add(15 * time.Minute) {
+		wallClock := time.Date(ts.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), 0, 0, time.UTC)
+		values = append(values, solaredge.Value{Date: solaredge.Time(wallClock), Value: value})
+	}
+	return values
+}
+
+func TestIntegrate(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Brussels")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		day          int
+		month        time.Month
+		wantDuration time.Duration
+		wantEnergy   float64
+	}{
+		{name: "regular day", month: time.June, day: 1, wantDuration: 24 * time.Hour, wantEnergy: 24000},
+		{name: "dst starts", month: time.March, day: 31, wantDuration: 23 * time.Hour, wantEnergy: 23000},
+		{name: "dst ends", month: time.October, day: 27, wantDuration: 25 * time.Hour, wantEnergy: 25000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := quarterHours(2024, tt.month, tt.day, loc, 1000)
+			days := Integrate(values, 15*time.Minute, solaredge.TimeUnitDay, loc)
+			if len(days) != 1 {
+				t.Fatalf("got %d periods, want 1", len(days))
+			}
+			if got := days[0].Duration(); got != tt.wantDuration {
+				t.Errorf("got duration %v, want %v", got, tt.wantDuration)
+			}
+			if got := days[0].Value; got != tt.wantEnergy {
+				t.Errorf("got energy %v, want %v", got, tt.wantEnergy)
+			}
+			if got := days[0].Start; !got.Equal(time.Date(2024, tt.month, tt.day, 0, 0, 0, 0, loc)) {
+				t.Errorf("got start %v", got)
+			}
+		})
+	}
+}
+
+func TestSum(t *testing.T) {
+	values := quarterHours(2024, time.June, 1, time.UTC, 250)
+	hours := Sum(values, solaredge.TimeUnitHour, nil)
+	if len(hours) != 24 {
+		t.Fatalf("got %d periods, want 24", len(hours))
+	}
+	for _, h := range hours {
+		if h.Value != 1000 || h.Count != 4 || h.Duration() != time.Hour {
+			t.Errorf("unexpected period: %+v", h)
+		}
+	}
+}
+
+func TestAverage(t *testing.T) {
+	values := []solaredge.Value{
+		{Date: solaredge.Time(time.Date(2024, time.June, 1, 10, 0, 0, 0, time.UTC)), Value: 100},
+		{Date: solaredge.Time(time.Date(2024, time.June, 1, 10, 15, 0, 0, time.UTC)), Value: 200},
+		{Date: solaredge.Time(time.Date(2024, time.June, 1, 11, 0, 0, 0, time.UTC)), Value: 400},
+	}
+	hours := Average(values, solaredge.TimeUnitHour, time.UTC)
+	if len(hours) != 2 {
+		t.Fatalf("got %d periods, want 2", len(hours))
+	}
+	if hours[0].Value != 150 || hours[1].Value != 400 {
+		t.Errorf("unexpected averages: %+v", hours)
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Package analytics derives key performance indicators from the measurements returned by the SolarEdge API.
+*/
+package analytics
+
+import (
+	"github.com/clambin/solaredge/v2"
+	"slices"
+	"time"
+)
+
+// Meter types, as reported by solaredge.Client.GetEnergyDetails and solaredge.Client.GetPowerDetails.
+const (
+	MeterProduction      = "Production"
+	MeterConsumption     = "Consumption"
+	MeterSelfConsumption = "SelfConsumption"
+	MeterFeedIn          = "FeedIn"
+	MeterPurchased       = "Purchased"
+)
+
+// EnergyBalance holds the energy flows of a site for one period.
+//
+// Meters that are not reported by the site are derived from the other meters where possible:
+//
+//	Production  = SelfConsumption + FeedIn
+//	Consumption = SelfConsumption + Purchased
+//
+// Use Has to determine whether a value was reported or derived, or is unavailable altogether.
+type EnergyBalance struct {
+	Start           time.Time
+	Production      float64
+	Consumption     float64
+	SelfConsumption float64
+	FeedIn          float64
+	Purchased       float64
+	available       meterSet
+}
+
+type meterSet uint8
+
+const (
+	hasProduction meterSet = 1 << iota
+	hasConsumption
+	hasSelfConsumption
+	hasFeedIn
+	hasPurchased
+)
+
+var meterFlags = map[string]meterSet{
+	MeterProduction:      hasProduction,
+	MeterConsumption:     hasConsumption,
+	MeterSelfConsumption: hasSelfConsumption,
+	MeterFeedIn:          hasFeedIn,
+	MeterPurchased:       hasPurchased,
+}
+
+// Has returns true if the value for the meter type is available, either because it was reported or because it could
+// be derived from the other meters.
+func (b EnergyBalance) Has(meter string) bool {
+	flag, ok := meterFlags[meter]
+	return ok && b.available&flag != 0
+}
+
+// SelfConsumptionRatio returns the share of the produced energy that was consumed on site.
+// Returns false if the ratio cannot be determined.
+func (b EnergyBalance) SelfConsumptionRatio() (float64, bool) {
+	return b.ratio(b.SelfConsumption, hasSelfConsumption, b.Production, hasProduction)
+}
+
+// SelfSufficiency (or autarky) returns the share of the consumed energy that was produced on site.
+// Returns false if the ratio cannot be determined.
+func (b EnergyBalance) SelfSufficiency() (float64, bool) {
+	return b.ratio(b.SelfConsumption, hasSelfConsumption, b.Consumption, hasConsumption)
+}
+
+// ExportRatio returns the share of the produced energy that was fed into the grid.
+// Returns false if the ratio cannot be determined.
+func (b EnergyBalance) ExportRatio() (float64, bool) {
+	return b.ratio(b.FeedIn, hasFeedIn, b.Production, hasProduction)
+}
+
+func (b EnergyBalance) ratio(numerator float64, numeratorFlag meterSet, denominator float64, denominatorFlag meterSet) (float64, bool) {
+	if b.available&numeratorFlag == 0 || b.available&denominatorFlag == 0 || denominator == 0 {
+		return 0, false
+	}
+	return numerator / denominator, true
+}
+
+func (b *EnergyBalance) set(meter string, value float64) {
+	switch meter {
+	case MeterProduction:
+		b.Production += value
+	case MeterConsumption:
+		b.Consumption += value
+	case MeterSelfConsumption:
+		b.SelfConsumption += value
+	case MeterFeedIn:
+		b.FeedIn += value
+	case MeterPurchased:
+		b.Purchased += value
+	default:
+		return
+	}
+	b.available |= meterFlags[meter]
+}
+
+// derive fills in the missing meters that can be calculated from the available ones.
+func (b *EnergyBalance) derive() {
+	for {
+		before := b.available
+		has := func(flags meterSet) bool { return b.available&flags == flags }
+		switch {
+		case !has(hasSelfConsumption) && has(hasProduction|hasFeedIn):
+			b.SelfConsumption = b.Production - b.FeedIn
+			b.available |= hasSelfConsumption
+		case !has(hasSelfConsumption) && has(hasConsumption|hasPurchased):
+			b.SelfConsumption = b.Consumption - b.Purchased
+			b.available |= hasSelfConsumption
+		case !has(hasProduction) && has(hasSelfConsumption|hasFeedIn):
+			b.Production = b.SelfConsumption + b.FeedIn
+			b.available |= hasProduction
+		case !has(hasConsumption) && has(hasSelfConsumption|hasPurchased):
+			b.Consumption = b.SelfConsumption + b.Purchased
+			b.available |= hasConsumption
+		case !has(hasFeedIn) && has(hasProduction|hasSelfConsumption):
+			b.FeedIn = b.Production - b.SelfConsumption
+			b.available |= hasFeedIn
+		case !has(hasPurchased) && has(hasConsumption|hasSelfConsumption):
+			b.Purchased = b.Consumption - b.SelfConsumption
+			b.available |= hasPurchased
+		}
+		if b.available == before {
+			return
+		}
+	}
+}
+
+// EnergyReport contains the energy balance for each period reported by solaredge.Client.GetEnergyDetails,
+// as well as the total over all periods.
+type EnergyReport struct {
+	TimeUnit solaredge.TimeUnit
+	Unit     solaredge.Unit
+	Periods  []EnergyBalance
+	Total    EnergyBalance
+}
+
+// AnalyzeEnergy calculates the energy balance for each period in the provided EnergyDetails. The periods have the
+// TimeUnit of the request that produced the EnergyDetails.
+func AnalyzeEnergy(details solaredge.EnergyDetails) EnergyReport {
+	periods := make(map[time.Time]*EnergyBalance)
+	for _, meter := range details.Meters {
+		if _, ok := meterFlags[meter.Type]; !ok {
+			continue
+		}
+		for _, value := range meter.Values {
+			start := time.Time(value.Date)
+			b, ok := periods[start]
+			if !ok {
+				b = &EnergyBalance{Start: start}
+				periods[start] = b
+			}
+			b.set(meter.Type, value.Value)
+		}
+	}
+
+	report := EnergyReport{
+		TimeUnit: details.TimeUnit,
+		Unit:     details.Unit,
+		Periods:  make([]EnergyBalance, 0, len(periods)),
+	}
+	for _, b := range periods {
+		b.derive()
+		report.Periods = append(report.Periods, *b)
+	}
+	slices.SortFunc(report.Periods, func(a, b EnergyBalance) int { return a.Start.Compare(b.Start) })
+	report.Total = total(report.Periods)
+	return report
+}
+
+// GroupBy aggregates the periods in the report to a coarser TimeUnit. Periods are aligned to calendar boundaries in
+// the provided location. If loc is nil, timestamps are grouped based on the site's local time, as reported by the API.
+func (r EnergyReport) GroupBy(unit solaredge.TimeUnit, loc *time.Location) EnergyReport {
+	groups := make(map[time.Time][]EnergyBalance)
+	for _, b := range r.Periods {
+		start := periodStart(solaredge.Time(b.Start).Local(loc), unit)
+		groups[start] = append(groups[start], b)
+	}
+	grouped := EnergyReport{
+		TimeUnit: unit,
+		Unit:     r.Unit,
+		Periods:  make([]EnergyBalance, 0, len(groups)),
+		Total:    r.Total,
+	}
+	for start, group := range groups {
+		b := total(group)
+		b.Start = start
+		grouped.Periods = append(grouped.Periods, b)
+	}
+	slices.SortFunc(grouped.Periods, func(a, b EnergyBalance) int { return a.Start.Compare(b.Start) })
+	return grouped
+}
+
+// total adds up the energy balances of all periods. A value is only available in the total if it is available for each period.
+func total(periods []EnergyBalance) EnergyBalance {
+	var t EnergyBalance
+	for i, b := range periods {
+		if i == 0 {
+			t.Start = b.Start
+			t.available = b.available
+		}
+		t.Production += b.Production
+		t.Consumption += b.Consumption
+		t.SelfConsumption += b.SelfConsumption
+		t.FeedIn += b.FeedIn
+		t.Purchased += b.Purchased
+		t.available &= b.available
+	}
+	return t
+}
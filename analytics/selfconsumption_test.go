@@ -0,0 +1,137 @@
+package analytics
+
+import (
+	"github.com/clambin/solaredge/v2"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeEnergy(t *testing.T) {
+	day1 := solaredge.Time(time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC))
+	day2 := solaredge.Time(time.Date(2024, time.June, 2, 0, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name                     string
+		meters                   []solaredge.MeterReadings
+		wantPeriods              int
+		wantTotal                EnergyBalance
+		wantSelfConsumptionRatio float64
+		wantSelfSufficiency      float64
+		wantExportRatio          float64
+		wantRatios               bool
+	}{
+		{
+			name: "all meters",
+			meters: []solaredge.MeterReadings{
+				{Type: MeterProduction, Values: []solaredge.Value{{Date: day1, Value: 10}, {Date: day2, Value: 30}}},
+				{Type: MeterConsumption, Values: []solaredge.Value{{Date: day1, Value: 20}, {Date: day2, Value: 20}}},
+				{Type: MeterSelfConsumption, Values: []solaredge.Value{{Date: day1, Value: 5}, {Date: day2, Value: 15}}},
+				{Type: MeterFeedIn, Values: []solaredge.Value{{Date: day1, Value: 5}, {Date: day2, Value: 15}}},
+				{Type: MeterPurchased, Values: []solaredge.Value{{Date: day1, Value: 15}, {Date: day2, Value: 5}}},
+			},
+			wantPeriods:              2,
+			wantTotal:                EnergyBalance{Production: 40, Consumption: 40, SelfConsumption: 20, FeedIn: 20, Purchased: 20},
+			wantSelfConsumptionRatio: 0.5,
+			wantSelfSufficiency:      0.5,
+			wantExportRatio:          0.5,
+			wantRatios:               true,
+		},
+		{
+			name: "derived meters",
+			meters: []solaredge.MeterReadings{
+				{Type: MeterProduction, Values: []solaredge.Value{{Date: day1, Value: 40}}},
+				{Type: MeterFeedIn, Values: []solaredge.Value{{Date: day1, Value: 10}}},
+				{Type: MeterPurchased, Values: []solaredge.Value{{Date: day1, Value: 10}}},
+			},
+			wantPeriods:              1,
+			wantTotal:                EnergyBalance{Production: 40, Consumption: 40, SelfConsumption: 30, FeedIn: 10, Purchased: 10},
+			wantSelfConsumptionRatio: 0.75,
+			wantSelfSufficiency:      0.75,
+			wantExportRatio:          0.25,
+			wantRatios:               true,
+		},
+		{
+			name: "insufficient meters",
+			meters: []solaredge.MeterReadings{
+				{Type: MeterProduction, Values: []solaredge.Value{{Date: day1, Value: 40}}},
+				{Type: "Unknown", Values: []solaredge.Value{{Date: day1, Value: 10}}},
+			},
+			wantPeriods: 1,
+			wantTotal:   EnergyBalance{Production: 40},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := AnalyzeEnergy(solaredge.EnergyDetails{TimeUnit: solaredge.TimeUnitDay, Unit: solaredge.UnitWh, Meters: tt.meters})
+			if len(r.Periods) != tt.wantPeriods {
+				t.Fatalf("got %d periods, want %d", len(r.Periods), tt.wantPeriods)
+			}
+			if !r.Periods[0].Start.Equal(time.Time(day1)) {
+				t.Errorf("got start %v, want %v", r.Periods[0].Start, time.Time(day1))
+			}
+			got := r.Total
+			if got.Production != tt.wantTotal.Production ||
+				got.Consumption != tt.wantTotal.Consumption ||
+				got.SelfConsumption != tt.wantTotal.SelfConsumption ||
+				got.FeedIn != tt.wantTotal.FeedIn ||
+				got.Purchased != tt.wantTotal.Purchased {
+				t.Errorf("got total %+v, want %+v", got, tt.wantTotal)
+			}
+
+			ratios := []struct {
+				name string
+				f    func() (float64, bool)
+				want float64
+			}{
+				{name: "SelfConsumptionRatio", f: got.SelfConsumptionRatio, want: tt.wantSelfConsumptionRatio},
+				{name: "SelfSufficiency", f: got.SelfSufficiency, want: tt.wantSelfSufficiency},
+				{name: "ExportRatio", f: got.ExportRatio, want: tt.wantExportRatio},
+			}
+			for _, ratio := range ratios {
+				value, ok := ratio.f()
+				if ok != tt.wantRatios {
+					t.Errorf("%s: got ok %v, want %v", ratio.name, ok, tt.wantRatios)
+				}
+				if math.Abs(value-ratio.want) > 1e-9 {
+					t.Errorf("%s: got %v, want %v", ratio.name, value, ratio.want)
+				}
+			}
+		})
+	}
+}
+
+func TestEnergyReport_GroupBy(t *testing.T) {
+	var values []solaredge.Value
+	for day := 1; day <= 31; day++ {
+		values = append(values, solaredge.Value{Date: solaredge.Time(time.Date(2024, time.May, day, 0, 0, 0, 0, time.UTC)), Value: 1})
+	}
+	values = append(values, solaredge.Value{Date: solaredge.Time(time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)), Value: 1})
+
+	r := AnalyzeEnergy(solaredge.EnergyDetails{
+		TimeUnit: solaredge.TimeUnitDay,
+		Meters:   []solaredge.MeterReadings{{Type: MeterProduction, Values: values}},
+	})
+
+	loc, err := time.LoadLocation("Europe/Brussels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	monthly := r.GroupBy(solaredge.TimeUnitMonth, loc)
+	if len(monthly.Periods) != 2 {
+		t.Fatalf("got %d periods, want 2", len(monthly.Periods))
+	}
+	if got := monthly.Periods[0]; got.Production != 31 || !got.Start.Equal(time.Date(2024, time.May, 1, 0, 0, 0, 0, loc)) {
+		t.Errorf("unexpected first period: %+v", got)
+	}
+	if got := monthly.Periods[1]; got.Production != 1 || !got.Start.Equal(time.Date(2024, time.June, 1, 0, 0, 0, 0, loc)) {
+		t.Errorf("unexpected second period: %+v", got)
+	}
+	if !monthly.Periods[0].Has(MeterProduction) || monthly.Periods[0].Has(MeterConsumption) {
+		t.Errorf("unexpected meters available: %+v", monthly.Periods[0])
+	}
+	if monthly.Total.Production != 32 {
+		t.Errorf("got total production %v, want 32", monthly.Total.Production)
+	}
+}
@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"github.com/clambin/solaredge/v2"
+	"math"
+	"slices"
+	"time"
+)
+
+// InverterYield contains the energy produced by an inverter over a period, normalized by the peak power of the
+// PV modules connected to it, and its deviation from the other inverters at the same site.
+type InverterYield struct {
+	SerialNumber string
+	Name         string
+	// Energy is the energy produced during the period, in Wh.
+	Energy float64
+	// PeakPower is the estimated peak power (in kWp) of the PV modules connected to the inverter.
+	PeakPower float64
+	// SpecificYield is the energy produced per kWp, in Wh/kWp.
+	SpecificYield float64
+	// Deviation is the relative difference between the inverter's specific yield and the median specific yield of all
+	// inverters at the site. E.g. -0.2 means the inverter produced 20% less than its peers.
+	Deviation float64
+	// Deviates is true if the absolute Deviation exceeds the threshold passed to CompareInverters.
+	Deviates bool
+}
+
+// CompareInverters compares the specific yield of the inverters at a site.
+//
+// The API does not report the peak power connected to each inverter. CompareInverters therefore divides the site's
+// peak power (SiteDetails.PeakPower, in kWp) over the inverters in the Inventory, proportionally to the number of
+// optimizers connected to each inverter. If the number of optimizers is not reported, the peak power is divided equally.
+//
+// telemetries holds the technical data of each inverter, keyed by serial number, as returned by
+// solaredge.Client.GetInverterTechnicalData. The energy produced by an inverter is the difference between the
+// TotalEnergy of its first and last telemetry. Inverters without telemetry are not reported.
+//
+// threshold is the relative deviation from the median specific yield beyond which an inverter is flagged.
+// At least two inverters are needed to flag any deviations.
+func CompareInverters(details solaredge.SiteDetails, inventory solaredge.Inventory, telemetries map[string][]solaredge.InverterTelemetry, threshold float64) []InverterYield {
+	var optimizers int
+	for _, inverter := range inventory.Inverters {
+		optimizers += inverter.ConnectedOptimizers
+	}
+
+	yields := make([]InverterYield, 0, len(inventory.Inverters))
+	for _, inverter := range inventory.Inverters {
+		energy, ok := producedEnergy(telemetries[inverter.SN])
+		if !ok {
+			continue
+		}
+		peakPower := details.PeakPower / float64(len(inventory.Inverters))
+		if optimizers > 0 {
+			peakPower = details.PeakPower * float64(inverter.ConnectedOptimizers) / float64(optimizers)
+		}
+		y := InverterYield{SerialNumber: inverter.SN, Name: inverter.Name, Energy: energy, PeakPower: peakPower}
+		if peakPower > 0 {
+			y.SpecificYield = energy / peakPower
+		}
+		yields = append(yields, y)
+	}
+
+	if len(yields) < 2 {
+		return yields
+	}
+	specificYields := make([]float64, len(yields))
+	for i, y := range yields {
+		specificYields[i] = y.SpecificYield
+	}
+	m := median(specificYields)
+	if m == 0 {
+		return yields
+	}
+	for i := range yields {
+		yields[i].Deviation = (yields[i].SpecificYield - m) / m
+		yields[i].Deviates = math.Abs(yields[i].Deviation) > threshold
+	}
+	return yields
+}
+
+// InverterDataSource provides the data needed by CompareSiteInverters. solaredge.Client implements this interface.
+type InverterDataSource interface {
+	GetSiteDetails(ctx context.Context, id int) (solaredge.GetSiteDetailsResponse, error)
+	GetInventory(ctx context.Context, id int) (solaredge.GetInventoryResponse, error)
+	GetInverterTechnicalData(ctx context.Context, id int, serialNr string, startTime, endTime time.Time) (solaredge.GetInverterTechnicalDataResponse, error)
+}
+
+// CompareSiteInverters retrieves the site's details, inventory and inverter technical data for the specified period,
+// and compares the inverters' specific yield. See CompareInverters for details.
+//
+// Note: GetInverterTechnicalData is limited to a one-week period.
+func CompareSiteInverters(ctx context.Context, c InverterDataSource, id int, start, end time.Time, threshold float64) ([]InverterYield, error) {
+	details, err := c.GetSiteDetails(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("site details: %w", err)
+	}
+	inventory, err := c.GetInventory(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("inventory: %w", err)
+	}
+	telemetries := make(map[string][]solaredge.InverterTelemetry, len(inventory.Inventory.Inverters))
+	for _, inverter := range inventory.Inventory.Inverters {
+		data, err := c.GetInverterTechnicalData(ctx, id, inverter.SN, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("technical data for %s: %w", inverter.SN, err)
+		}
+		telemetries[inverter.SN] = data.Data.Telemetries
+	}
+	return CompareInverters(details.Details, inventory.Inventory, telemetries, threshold), nil
+}
+
+func producedEnergy(telemetries []solaredge.InverterTelemetry) (float64, bool) {
+	if len(telemetries) == 0 {
+		return 0, false
+	}
+	first, last := telemetries[0], telemetries[0]
+	for _, t := range telemetries[1:] {
+		if time.Time(t.Time).Before(time.Time(first.Time)) {
+			first = t
+		}
+		if time.Time(t.Time).After(time.Time(last.Time)) {
+			last = t
+		}
+	}
+	return last.TotalEnergy - first.TotalEnergy, true
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,104 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"github.com/clambin/solaredge/v2"
+	"math"
+	"testing"
+	"time"
+)
+
+func telemetryWithEnergy(start, end float64) []solaredge.InverterTelemetry {
+	return []solaredge.InverterTelemetry{
+		{Time: solaredge.Time(time.Date(2024, time.June, 7, 0, 0, 0, 0, time.UTC)), TotalEnergy: end},
+		{Time: solaredge.Time(time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)), TotalEnergy: start},
+	}
+}
+
+func TestCompareInverters(t *testing.T) {
+	details := solaredge.SiteDetails{PeakPower: 25}
+	inventory := solaredge.Inventory{Inverters: []solaredge.InverterEquipment{
+		{SN: "SN1", ConnectedOptimizers: 10},
+		{SN: "SN2", ConnectedOptimizers: 10},
+		{SN: "SN3", ConnectedOptimizers: 20},
+		{SN: "SN4", ConnectedOptimizers: 10},
+	}}
+	telemetries := map[string][]solaredge.InverterTelemetry{
+		"SN1": telemetryWithEnergy(1000, 21000), // 5 kWp: 4000 Wh/kWp
+		"SN2": telemetryWithEnergy(0, 20000),    // 5 kWp: 4000 Wh/kWp
+		"SN3": telemetryWithEnergy(0, 24000),    // 10 kWp: 2400 Wh/kWp
+	}
+
+	yields := CompareInverters(details, inventory, telemetries, 0.2)
+	if len(yields) != 3 {
+		t.Fatalf("got %d yields, want 3", len(yields))
+	}
+	want := []struct {
+		sn            string
+		peakPower     float64
+		specificYield float64
+		deviation     float64
+		deviates      bool
+	}{
+		{sn: "SN1", peakPower: 5, specificYield: 4000, deviation: 0},
+		{sn: "SN2", peakPower: 5, specificYield: 4000, deviation: 0},
+		{sn: "SN3", peakPower: 10, specificYield: 2400, deviation: -0.4, deviates: true},
+	}
+	for i, w := range want {
+		got := yields[i]
+		if got.SerialNumber != w.sn || got.PeakPower != w.peakPower || got.SpecificYield != w.specificYield ||
+			math.Abs(got.Deviation-w.deviation) > 1e-9 || got.Deviates != w.deviates {
+			t.Errorf("yield %d: got %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestCompareInverters_SingleInverter(t *testing.T) {
+	yields := CompareInverters(
+		solaredge.SiteDetails{PeakPower: 5},
+		solaredge.Inventory{Inverters: []solaredge.InverterEquipment{{SN: "SN1"}}},
+		map[string][]solaredge.InverterTelemetry{"SN1": telemetryWithEnergy(0, 5000)},
+		0.1,
+	)
+	if len(yields) != 1 || yields[0].SpecificYield != 1000 || yields[0].Deviates {
+		t.Errorf("unexpected yields: %+v", yields)
+	}
+}
+
+type fakeInverterDataSource struct {
+	err error
+}
+
+func (f fakeInverterDataSource) GetSiteDetails(_ context.Context, id int) (solaredge.GetSiteDetailsResponse, error) {
+	return solaredge.GetSiteDetailsResponse{Details: solaredge.SiteDetails{Id: id, PeakPower: 10}}, nil
+}
+
+func (f fakeInverterDataSource) GetInventory(_ context.Context, _ int) (solaredge.GetInventoryResponse, error) {
+	return solaredge.GetInventoryResponse{Inventory: solaredge.Inventory{Inverters: []solaredge.InverterEquipment{{SN: "SN1"}, {SN: "SN2"}}}}, nil
+}
+
+func (f fakeInverterDataSource) GetInverterTechnicalData(_ context.Context, _ int, serialNr string, _, _ time.Time) (solaredge.GetInverterTechnicalDataResponse, error) {
+	var resp solaredge.GetInverterTechnicalDataResponse
+	if serialNr == "SN2" {
+		resp.Data.Telemetries = telemetryWithEnergy(0, 10000)
+	} else {
+		resp.Data.Telemetries = telemetryWithEnergy(0, 20000)
+	}
+	return resp, f.err
+}
+
+func TestCompareSiteInverters(t *testing.T) {
+	yields, err := CompareSiteInverters(context.Background(), fakeInverterDataSource{}, 1, time.Time{}, time.Time{}, 0.25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(yields) != 2 || !yields[0].Deviates || !yields[1].Deviates || yields[1].Deviation >= 0 {
+		t.Errorf("unexpected yields: %+v", yields)
+	}
+
+	_, err = CompareSiteInverters(context.Background(), fakeInverterDataSource{err: errors.New("fail")}, 1, time.Time{}, time.Time{}, 0.25)
+	if err == nil {
+		t.Error("expected an error")
+	}
+}
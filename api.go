@@ -0,0 +1,47 @@
+package solaredge
+
+import (
+	"context"
+	"time"
+)
+
+// SiteDataAPI implements the "Site Data API" section of the SolarEdge API.
+type SiteDataAPI interface {
+	GetSites(ctx context.Context) (GetSitesResponse, error)
+	GetSiteDetails(ctx context.Context, id int) (GetSiteDetailsResponse, error)
+	GetDataPeriod(ctx context.Context, id int) (GetDataPeriodResponse, error)
+	GetEnergyMeasurements(ctx context.Context, id int, timeUnit TimeUnit, startDate time.Time, endDate time.Time) (GetEnergyMeasurementsResponse, error)
+	GetEnergyForTimeFrame(ctx context.Context, id int, startDate, endDate time.Time) (GetEnergyForTimeframeResponse, error)
+	GetPowerMeasurements(ctx context.Context, id int, startTime, endTime time.Time) (GetPowerMeasurementsResponse, error)
+	GetPowerOverview(ctx context.Context, id int) (GetPowerOverviewResponse, error)
+	GetPowerDetails(ctx context.Context, id int, start, end time.Time) (GetPowerDetailsResponse, error)
+	GetEnergyDetails(ctx context.Context, id int, timeUnit TimeUnit, startTime, endTime time.Time) (GetEnergyDetailsResponse, error)
+	GetPowerFlow(ctx context.Context, id int) (GetPowerFlowResponse, error)
+	GetStorageData(ctx context.Context, id int, startTime, endTime time.Time) (GetStorageDataResponse, error)
+	GetEnvBenefits(ctx context.Context, id int) (GetEnvBenefitsResponse, error)
+}
+
+// EquipmentAPI implements the "Site Equipment API" section of the SolarEdge API.
+type EquipmentAPI interface {
+	GetComponents(ctx context.Context, id int) (GetComponentsResponse, error)
+	GetInventory(ctx context.Context, id int) (GetInventoryResponse, error)
+	GetInverterTechnicalData(ctx context.Context, id int, serialNr string, startTime, endTime time.Time) (GetInverterTechnicalDataResponse, error)
+	GetEquipmentChangeLog(ctx context.Context, id int, serialNr string) (GetEquipmentChangeLogResponse, error)
+}
+
+// VersionAPI implements the "API Versions" section of the SolarEdge API.
+type VersionAPI interface {
+	GetCurrentAPIVersion(ctx context.Context) (GetCurrentAPIVersionResponse, error)
+	GetSupportedAPIVersions(ctx context.Context) (GetSupportedAPIVersionsResponse, error)
+}
+
+// API is the complete SolarEdge API, as implemented by Client. Code depending on the client can accept an API
+// (or one of its narrower interfaces) instead of a *Client, so it can be tested with a mock implementation,
+// like solaredgetest.Mock.
+type API interface {
+	SiteDataAPI
+	EquipmentAPI
+	VersionAPI
+}
+
+var _ API = &Client{}
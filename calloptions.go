@@ -0,0 +1,74 @@
+package solaredge
+
+import (
+	"context"
+	"time"
+)
+
+// A CallOption changes how the Client performs a single call. Use WithCallOptions to attach CallOptions to the
+// context passed to the call.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	timeout     *time.Duration
+	retries     *int
+	priority    Priority
+	bypassCache bool
+}
+
+type callOptionsKey struct{}
+
+// WithCallOptions returns a copy of ctx holding the CallOptions. Calls made with the returned context (or a context
+// derived from it) use these options. Options already held by ctx are kept, unless overridden by opts.
+func WithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	o := callOptionsFromContext(ctx)
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return context.WithValue(ctx, callOptionsKey{}, o)
+}
+
+func callOptionsFromContext(ctx context.Context) callOptions {
+	o, _ := ctx.Value(callOptionsKey{}).(callOptions)
+	return o
+}
+
+// CallTimeout limits the duration of the call, overriding the timeout set by WithTimeout. Zero means no timeout.
+func CallTimeout(timeout time.Duration) CallOption {
+	return func(o *callOptions) { o.timeout = &timeout }
+}
+
+// CallRetries sets how many times a failed call is retried, overriding the number set by WithRetries.
+func CallRetries(retries int) CallOption {
+	return func(o *callOptions) { o.retries = &retries }
+}
+
+// CallPriority sets the priority of the call. A Limiter can use this (see PriorityFromContext) to let calls with a
+// higher priority go first.
+func CallPriority(priority Priority) CallOption {
+	return func(o *callOptions) { o.priority = priority }
+}
+
+// BypassCache ignores any cached response and retrieves the data again. The cache is updated with the new response.
+// This applies to SiteClient's Details, Location and Inventory.
+func BypassCache() CallOption {
+	return func(o *callOptions) { o.bypassCache = true }
+}
+
+// Priority of a call. Calls have PriorityNormal, unless set otherwise with CallPriority.
+type Priority int
+
+const (
+	// PriorityBackground is meant for calls that aren't time-critical, e.g. downloading a site's history.
+	PriorityBackground Priority = -1
+	// PriorityNormal is the default priority.
+	PriorityNormal Priority = 0
+	// PriorityInteractive is meant for calls a user is waiting for.
+	PriorityInteractive Priority = 1
+)
+
+// PriorityFromContext returns the priority set by CallPriority, or PriorityNormal if the context has no priority.
+// Limiters can use this to order the calls waiting to be sent.
+func PriorityFromContext(ctx context.Context) Priority {
+	return callOptionsFromContext(ctx).priority
+}
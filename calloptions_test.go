@@ -0,0 +1,134 @@
+package solaredge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithCallOptions(t *testing.T) {
+	ctx := WithCallOptions(context.Background(), CallTimeout(time.Second), CallPriority(PriorityInteractive))
+	ctx = WithCallOptions(ctx, CallRetries(2))
+
+	o := callOptionsFromContext(ctx)
+	if o.timeout == nil || *o.timeout != time.Second {
+		t.Errorf("timeout not kept: %v", o.timeout)
+	}
+	if o.retries == nil || *o.retries != 2 {
+		t.Errorf("retries not set: %v", o.retries)
+	}
+	if o.bypassCache {
+		t.Error("unexpected bypassCache")
+	}
+	if got := PriorityFromContext(ctx); got != PriorityInteractive {
+		t.Errorf("got priority %d, want %d", got, PriorityInteractive)
+	}
+	if got := PriorityFromContext(context.Background()); got != PriorityNormal {
+		t.Errorf("got priority %d, want %d", got, PriorityNormal)
+	}
+}
+
+func TestClient_CallTimeout(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer s.Close()
+
+	c, err := NewClient(validKey, WithBaseURL(s.URL), WithTimeout(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithCallOptions(context.Background(), CallTimeout(100*time.Millisecond))
+	if _, err = c.GetCurrentAPIVersion(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	defer func(backoff time.Duration) { retryBackoff = backoff }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	var calls atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/notfound" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/quota" {
+			calls.Add(1)
+			http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+			return
+		}
+		// fail every other call
+		if calls.Add(1)%2 == 1 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"version":{"release":"1.0.0"}}`))
+	}))
+	defer s.Close()
+
+	c, err := NewClient(validKey, WithBaseURL(s.URL), WithRetries(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	calls.Store(0)
+	if _, err = c.GetCurrentAPIVersion(ctx); err != nil {
+		t.Errorf("expected the call to be retried, got %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("got %d calls, want 2", got)
+	}
+
+	calls.Store(0)
+	if _, err = c.GetCurrentAPIVersion(WithCallOptions(ctx, CallRetries(0))); err == nil {
+		t.Error("expected the call to fail without retries")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("got %d calls, want 1", got)
+	}
+
+	calls.Store(0)
+	if _, err = call[GetCurrentAPIVersionResponse](ctx, c, "/notfound", nil); err == nil {
+		t.Error("expected the call to fail")
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("got %d calls, want 0", got)
+	}
+
+	// an exhausted quota isn't retried
+	calls.Store(0)
+	if _, err = call[GetCurrentAPIVersionResponse](ctx, c, "/quota", nil); err == nil {
+		t.Error("expected the call to fail")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("got %d calls, want 1", got)
+	}
+
+	// limiter errors aren't retried
+	calls.Store(0)
+	var waits atomic.Int32
+	c.limiter = failingLimiter{waits: &waits}
+	if _, err = c.GetCurrentAPIVersion(ctx); err == nil {
+		t.Error("expected the call to fail")
+	}
+	if got := waits.Load(); got != 1 || calls.Load() != 0 {
+		t.Errorf("got %d waits and %d calls, want 1 and 0", got, calls.Load())
+	}
+}
+
+type failingLimiter struct {
+	waits *atomic.Int32
+}
+
+func (l failingLimiter) Wait(context.Context) error {
+	l.waits.Add(1)
+	return errors.New("rate limit exceeded")
+}
@@ -32,6 +32,17 @@ func (t Time) MarshalJSON() ([]byte, error) {
 	return []byte(time.Time(t).Format(`"2006-01-02 15:04:05"`)), nil
 }
 
+// Local returns the moment t represents at a site in the time zone loc. The API reports timestamps in the site's local
+// time, without a time zone, so Time holds the site's wall clock time in UTC. Local keeps the wall clock time, but
+// places it in loc. If loc is nil, Local returns t unchanged, i.e. in UTC.
+func (t Time) Local(loc *time.Location) time.Time {
+	ts := time.Time(t)
+	if loc == nil {
+		return ts
+	}
+	return time.Date(ts.Year(), ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), ts.Nanosecond(), loc)
+}
+
 // Value is a common data type in the SolarEdge API. It represents a measurement at a moment in time.
 type Value struct {
 	Date  Time    `json:"date"`
@@ -0,0 +1,20 @@
+package solaredge
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTime_Local(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := Time(time.Date(2024, time.March, 14, 13, 47, 12, 0, time.UTC))
+	if got, want := ts.Local(loc), time.Date(2024, time.March, 14, 13, 47, 12, 0, loc); !got.Equal(want) || got.Location() != loc {
+		t.Errorf("Local() = %v, want %v", got, want)
+	}
+	if got := ts.Local(nil); !got.Equal(time.Time(ts)) {
+		t.Errorf("Local(nil) = %v, want %v", got, time.Time(ts))
+	}
+}
@@ -4,10 +4,39 @@ import (
 	"context"
 	"fmt"
 	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/solaredgetest"
+	"log/slog"
 	"os"
 	"time"
 )
 
+func ExampleNewClient() {
+	// a fake SolarEdge server, so the example runs without an API key
+	const apiKey = "ABCDEFGHIJKLMNOPQRSTUVWXYZ012345"
+	var site solaredgetest.Site
+	site.Details.Id = 1
+	s := solaredgetest.NewServer(apiKey, site)
+	defer s.Close()
+
+	c, err := solaredge.NewClient(apiKey,
+		solaredge.WithHTTPClient(s.Client()),
+		solaredge.WithUserAgent("my-app/1.0"),
+		solaredge.WithTimeout(10*time.Second),
+		solaredge.WithLogger(slog.Default()),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	resp, err := c.GetSites(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("Sites: %d\n", resp.Sites.Count)
+	// Output:
+	// Sites: 1
+}
+
 func ExampleClient_GetSites() {
 	ctx := context.Background()
 	c := solaredge.Client{SiteKey: os.Getenv("SOLAREDGE_APIKEY")}
@@ -2,13 +2,29 @@ package solaredge
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/url"
+	"strings"
 	"time"
+	"unicode"
 )
 
 // This file implements the "Site Equipment API" section of the SolarEdge API specifications.
 // https://knowledge-center.solaredge.com/sites/kc/files/se_monitoring_api.pdf
 
+// ErrInvalidSerialNumber is returned when a serial number can't be used as a path segment: it is empty, "." or "..",
+// or holds control characters. Serial numbers are escaped in the request's path, so any other character is allowed
+// (e.g. the dots in a meter's or gateway's serial number).
+var ErrInvalidSerialNumber = errors.New("invalid serial number")
+
+func validateSerialNumber(serialNr string) error {
+	if serialNr == "" || serialNr == "." || serialNr == ".." || strings.ContainsFunc(serialNr, unicode.IsControl) {
+		return fmt.Errorf("%w: %q", ErrInvalidSerialNumber, serialNr)
+	}
+	return nil
+}
+
 // GetComponents returns a list of inverters/SMIs in the specific site.
 func (c *Client) GetComponents(ctx context.Context, id int) (GetComponentsResponse, error) {
 	return call[GetComponentsResponse](ctx, c, makePath("/equipment/{siteId}/list", id), nil)
@@ -101,12 +117,16 @@ type SensorEquipment struct {
 // Notes:
 //   - This API is limited to a one-week period. If the time range exceeds one week, an error is returned.
 //   - this may not be fully complete, as data returned for my account doesn't match the specifications.
+//   - If serialNr is not a valid serial number, ErrInvalidSerialNumber is returned without calling the API.
 func (c *Client) GetInverterTechnicalData(ctx context.Context, id int, serialNr string, startTime, endTime time.Time) (GetInverterTechnicalDataResponse, error) {
 	args := url.Values{
 		"startTime": []string{startTime.Format(timeFormat)},
 		"endTime":   []string{endTime.Format(timeFormat)},
 	}
-	return call[GetInverterTechnicalDataResponse](ctx, c, makePath("/equipment/{siteId}/"+serialNr+"/data", id), args)
+	if err := validateSerialNumber(serialNr); err != nil {
+		return GetInverterTechnicalDataResponse{}, err
+	}
+	return call[GetInverterTechnicalDataResponse](ctx, c, makePath("/equipment/{siteId}/{serialNumber}/data", id, serialNr), args)
 }
 
 type GetInverterTechnicalDataResponse struct {
@@ -141,8 +161,13 @@ type InverterTelemetryL1Data struct {
 }
 
 // GetEquipmentChangeLog returns a list of equipment component replacements ordered by date. This method is applicable to inverters, optimizers, batteries and gateways.
+//
+// If serialNr is not a valid serial number, ErrInvalidSerialNumber is returned without calling the API.
 func (c *Client) GetEquipmentChangeLog(ctx context.Context, id int, serialNr string) (GetEquipmentChangeLogResponse, error) {
-	return call[GetEquipmentChangeLogResponse](ctx, c, makePath("/equipment/{siteId}/"+serialNr+"/changeLog", id), nil)
+	if err := validateSerialNumber(serialNr); err != nil {
+		return GetEquipmentChangeLogResponse{}, err
+	}
+	return call[GetEquipmentChangeLogResponse](ctx, c, makePath("/equipment/{siteId}/{serialNumber}/changeLog", id, serialNr), nil)
 }
 
 type GetEquipmentChangeLogResponse struct {
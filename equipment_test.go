@@ -2,7 +2,9 @@ package solaredge
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -30,3 +32,56 @@ func TestClient_GetEquipmentChangeLog(t *testing.T) {
 	resp, err := c.GetEquipmentChangeLog(context.Background(), 1, "SN1")
 	expect(t, resp, "/equipment/1/SN1/changeLog", err)
 }
+
+func TestClient_InvalidSerialNumber(t *testing.T) {
+	var requests int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer s.Close()
+	c := Client{baseURL: s.URL, HTTPClient: http.DefaultClient}
+	ctx := context.Background()
+
+	for _, serialNr := range []string{"", ".", "..", "SN1\n", "SN\x001"} {
+		if _, err := c.GetInverterTechnicalData(ctx, 1, serialNr, time.Time{}, time.Time{}); !errors.Is(err, ErrInvalidSerialNumber) {
+			t.Errorf("%q: expected ErrInvalidSerialNumber, got %v", serialNr, err)
+		}
+		if _, err := c.GetEquipmentChangeLog(ctx, 1, serialNr); !errors.Is(err, ErrInvalidSerialNumber) {
+			t.Errorf("%q: expected ErrInvalidSerialNumber, got %v", serialNr, err)
+		}
+	}
+	if requests != 0 {
+		t.Errorf("got %d requests, want 0", requests)
+	}
+}
+
+func TestClient_EscapedSerialNumber(t *testing.T) {
+	var path string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+	c := Client{baseURL: s.URL, HTTPClient: http.DefaultClient}
+	ctx := context.Background()
+
+	tests := []struct {
+		serialNr string
+		want     string
+	}{
+		{serialNr: "7E1234AB-12", want: "/equipment/1/7E1234AB-12/changeLog"},
+		{serialNr: "606.12345.67", want: "/equipment/1/606.12345.67/changeLog"},
+		{serialNr: "SN1/../list", want: "/equipment/1/SN1%2F..%2Flist/changeLog"},
+		{serialNr: "SN1?startTime=now", want: "/equipment/1/SN1%3FstartTime=now/changeLog"},
+		{serialNr: "SN 1#data", want: "/equipment/1/SN%201%23data/changeLog"},
+	}
+	for _, tt := range tests {
+		if _, err := c.GetEquipmentChangeLog(ctx, 1, tt.serialNr); err != nil {
+			t.Fatalf("%q: %v", tt.serialNr, err)
+		}
+		if path != tt.want {
+			t.Errorf("%q: got path %q, want %q", tt.serialNr, path, tt.want)
+		}
+	}
+}
@@ -1,18 +1,107 @@
 package solaredge
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"golang.org/x/net/html"
 	"io"
+	"mime"
 	"net/http"
+	"strings"
 )
 
+// UnexpectedResponseError is returned when the server returns a response that isn't JSON, e.g. when a proxy
+// returns an HTML login page.
+type UnexpectedResponseError struct {
+	// ContentType of the response.
+	ContentType string
+	// Snippet holds the start of the response's body.
+	Snippet    string
+	StatusCode int
+}
+
+func (e *UnexpectedResponseError) Error() string {
+	return fmt.Sprintf("unexpected response: status %d, content type %q: %q", e.StatusCode, e.ContentType, e.Snippet)
+}
+
+// ErrResponseTooLarge is returned when the size of a response exceeds the limit set by WithMaxResponseSize.
+var ErrResponseTooLarge = errors.New("response too large")
+
+// snippetSize is the maximum size of UnexpectedResponseError's Snippet.
+const snippetSize = 256
+
+// checkJSON verifies that the response holds JSON. Responses without a Content-Type, or with a text/plain one,
+// are accepted if the body looks like JSON. If the response isn't JSON, checkJSON returns an UnexpectedResponseError.
+func checkJSON(r *http.Response) error {
+	contentType := r.Header.Get("Content-Type")
+	var mediaType string
+	if contentType != "" {
+		mediaType, _, _ = mime.ParseMediaType(contentType)
+		if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+			return nil
+		}
+	}
+	body := bufio.NewReaderSize(r.Body, snippetSize)
+	start, _ := body.Peek(snippetSize)
+	r.Body = readCloser{Reader: body, Closer: r.Body}
+	if contentType == "" || mediaType == "text/plain" {
+		if trimmed := bytes.TrimLeft(start, " \t\r\n"); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			return nil
+		}
+	}
+	return &UnexpectedResponseError{
+		StatusCode:  r.StatusCode,
+		ContentType: contentType,
+		Snippet:     strings.ToValidUTF8(string(start), ""),
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// limitBody returns a body that returns ErrResponseTooLarge once more than limit bytes have been read.
+func limitBody(body io.ReadCloser, limit int64) io.ReadCloser {
+	return readCloser{Reader: &limitedReader{reader: body, remaining: limit}, Closer: body}
+}
+
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	// read one byte more than allowed, to detect responses that exceed the limit
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), ErrResponseTooLarge
+	}
+	return n, err
+}
+
+// Error is returned when the API responds with an error.
 type Error struct {
-	vals map[string]any
+	vals   map[string]any
+	status string
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
 }
 
 func (e *Error) Error() string {
+	if e.vals == nil {
+		return "api error: " + e.status
+	}
 	return fmt.Sprintf("api error: %v", e.vals)
 }
 
@@ -22,18 +111,19 @@ func newResponseError(r *http.Response) error {
 		// parse json error
 		var e map[string]any
 		if err := json.NewDecoder(r.Body).Decode(&e); err == nil {
-			return &Error{vals: e}
+			return &Error{vals: e, StatusCode: r.StatusCode}
 		}
 	case "text/html":
 		if values := readHTMLError(r.Body); len(values) > 0 {
-			return &Error{vals: values}
+			return &Error{vals: values, StatusCode: r.StatusCode}
 		}
 	}
 	// if response is neither (valid) json nor html, report the HTTP Status Code (& Status, if available).
-	if r.Status != "" {
-		return fmt.Errorf("api error: %s", r.Status)
+	status := r.Status
+	if status == "" {
+		status = fmt.Sprintf("%d - %s", r.StatusCode, http.StatusText(r.StatusCode))
 	}
-	return fmt.Errorf("api error: %d - %s", r.StatusCode, http.StatusText(r.StatusCode))
+	return &Error{status: status, StatusCode: r.StatusCode}
 }
 
 func readHTMLError(r io.Reader) map[string]any {
@@ -1,8 +1,11 @@
 package solaredge
 
 import (
+	"context"
+	"errors"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 )
@@ -81,3 +84,109 @@ func TestError(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     bool
+	}{
+		{name: "json", contentType: "application/json;charset=UTF-8", body: `{}`},
+		{name: "json suffix", contentType: "application/problem+json", body: `{}`},
+		{name: "no content type", body: ` [1]`},
+		{name: "plain text holding json", contentType: "text/plain; charset=utf-8", body: `{}`},
+		{name: "no content type, not json", body: `hello`, wantErr: true},
+		{name: "html", contentType: "text/html", body: `<html><body>Please log in</body></html>`, wantErr: true},
+		{name: "html looking like json", contentType: "text/html", body: `{}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			if tt.contentType != "" {
+				resp.Header.Set("Content-Type", tt.contentType)
+			}
+			err := checkJSON(&resp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var unexpected *UnexpectedResponseError
+				if !errors.As(err, &unexpected) || unexpected.Snippet != tt.body || unexpected.ContentType != tt.contentType {
+					t.Errorf("unexpected error: %#v", err)
+				}
+			}
+			// checkJSON must not consume the body
+			if body, _ := io.ReadAll(resp.Body); string(body) != tt.body {
+				t.Errorf("got body %q, want %q", string(body), tt.body)
+			}
+		})
+	}
+}
+
+func TestLimitBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		limit   int64
+		wantErr bool
+	}{
+		{name: "below limit", body: "12345", limit: 10},
+		{name: "at limit", body: "1234567890", limit: 10},
+		{name: "above limit", body: "12345678901", limit: 10, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := io.ReadAll(limitBody(io.NopCloser(strings.NewReader(tt.body)), tt.limit))
+			if tt.wantErr {
+				if !errors.Is(err, ErrResponseTooLarge) {
+					t.Errorf("expected ErrResponseTooLarge, got %v", err)
+				}
+				if int64(len(body)) != tt.limit {
+					t.Errorf("got %d bytes, want %d", len(body), tt.limit)
+				}
+				return
+			}
+			if err != nil || string(body) != tt.body {
+				t.Errorf("got %q / %v, want %q", string(body), err, tt.body)
+			}
+		})
+	}
+}
+
+func TestClient_UnexpectedResponse(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><body>Please log in</body></html>`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"version":{"release":"1.0.0"}}`))
+		}
+	}))
+	defer s.Close()
+
+	c, err := NewClient(validKey, WithBaseURL(s.URL), WithMaxResponseSize(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	_, err = call[GetCurrentAPIVersionResponse](ctx, c, "/login", nil)
+	var unexpected *UnexpectedResponseError
+	if !errors.As(err, &unexpected) {
+		t.Fatalf("expected an UnexpectedResponseError, got %v", err)
+	}
+	if unexpected.StatusCode != http.StatusOK || !strings.HasPrefix(unexpected.Snippet, "<html>") {
+		t.Errorf("unexpected error: %#v", unexpected)
+	}
+
+	if _, err = c.GetCurrentAPIVersion(ctx); !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("expected ErrResponseTooLarge, got %v", err)
+	}
+}
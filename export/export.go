@@ -0,0 +1,155 @@
+/*
+Package export converts measurements returned by the SolarEdge API into formats understood by time-series databases:
+InfluxDB line protocol and Prometheus remote write.
+
+Measurements are first converted into Points, using consistent measurement names and tags:
+
+	measurement             tags            fields
+	solaredge_power         site            power
+	solaredge_energy        site            energy
+	solaredge_meter_power   site, meter     power
+	solaredge_meter_energy  site, meter     energy
+	solaredge_battery       site, serial    power, battery_state, lifetime_energy_charged, ...
+	solaredge_inverter      site, serial    total_active_power, dc_voltage, temperature, ...
+
+The API reports timestamps in the site's local time. Pass the site's time zone (see SiteDetails.Location.TimeZone)
+to the conversion functions, so each Point holds the actual moment of the measurement (see solaredge.Time.Local).
+
+Points can then be written to InfluxDB (see InfluxWriter) or to a Prometheus remote write endpoint (see RemoteWriter).
+*/
+package export
+
+import (
+	"github.com/clambin/solaredge/v2"
+	"strconv"
+	"time"
+)
+
+// Measurement names.
+const (
+	MeasurementPower       = "solaredge_power"
+	MeasurementEnergy      = "solaredge_energy"
+	MeasurementMeterPower  = "solaredge_meter_power"
+	MeasurementMeterEnergy = "solaredge_meter_energy"
+	MeasurementBattery     = "solaredge_battery"
+	MeasurementInverter    = "solaredge_inverter"
+)
+
+// Tag names.
+const (
+	TagSite   = "site"
+	TagMeter  = "meter"
+	TagSerial = "serial"
+)
+
+// A Point is a set of measurements at a moment in time.
+type Point struct {
+	Time        time.Time
+	Tags        map[string]string
+	Fields      map[string]float64
+	Measurement string
+}
+
+// Values converts values into Points. Use MeasurementPower and "power" for the values returned by
+// GetPowerMeasurements, and MeasurementEnergy and "energy" for the values returned by GetEnergyMeasurements.
+// loc is the site's time zone. If loc is nil, timestamps are interpreted as UTC.
+func Values(siteID int, measurement string, field string, values []solaredge.Value, loc *time.Location) []Point {
+	points := make([]Point, 0, len(values))
+	for _, v := range values {
+		points = append(points, Point{
+			Measurement: measurement,
+			Tags:        siteTags(siteID),
+			Fields:      map[string]float64{field: v.Value},
+			Time:        v.Date.Local(loc),
+		})
+	}
+	return points
+}
+
+// MeterPower converts the meter readings returned by GetPowerDetails into Points.
+func MeterPower(siteID int, readings []solaredge.MeterReadings, loc *time.Location) []Point {
+	return meterReadings(siteID, MeasurementMeterPower, "power", readings, loc)
+}
+
+// MeterEnergy converts the meter readings returned by GetEnergyDetails into Points.
+func MeterEnergy(siteID int, readings []solaredge.MeterReadings, loc *time.Location) []Point {
+	return meterReadings(siteID, MeasurementMeterEnergy, "energy", readings, loc)
+}
+
+func meterReadings(siteID int, measurement string, field string, readings []solaredge.MeterReadings, loc *time.Location) []Point {
+	var points []Point
+	for _, meter := range readings {
+		for _, v := range meter.Values {
+			tags := siteTags(siteID)
+			tags[TagMeter] = meter.Type
+			points = append(points, Point{
+				Measurement: measurement,
+				Tags:        tags,
+				Fields:      map[string]float64{field: v.Value},
+				Time:        v.Date.Local(loc),
+			})
+		}
+	}
+	return points
+}
+
+// BatteryTelemetry converts the telemetry of a battery, as returned by GetStorageData, into Points.
+func BatteryTelemetry(siteID int, serialNr string, telemetries []solaredge.BatteryTelemetry, loc *time.Location) []Point {
+	points := make([]Point, 0, len(telemetries))
+	for _, t := range telemetries {
+		points = append(points, Point{
+			Measurement: MeasurementBattery,
+			Tags:        serialTags(siteID, serialNr),
+			Fields: map[string]float64{
+				"power":                      t.Power,
+				"battery_state":              float64(t.BatteryState),
+				"lifetime_energy_charged":    t.LifeTimeEnergyCharged,
+				"lifetime_energy_discharged": t.LifeTimeEnergyDischarged,
+				"full_pack_energy_available": t.FullPackEnergyAvailable,
+				"internal_temperature":       t.InternalTemp,
+				"ac_grid_charging":           t.ACGridCharging,
+			},
+			Time: t.TimeStamp.Local(loc),
+		})
+	}
+	return points
+}
+
+// InverterTelemetry converts the technical data of an inverter, as returned by GetInverterTechnicalData, into Points.
+func InverterTelemetry(siteID int, serialNr string, telemetries []solaredge.InverterTelemetry, loc *time.Location) []Point {
+	points := make([]Point, 0, len(telemetries))
+	for _, t := range telemetries {
+		points = append(points, Point{
+			Measurement: MeasurementInverter,
+			Tags:        serialTags(siteID, serialNr),
+			Fields: map[string]float64{
+				"total_active_power":      t.TotalActivePower,
+				"total_energy":            t.TotalEnergy,
+				"dc_voltage":              t.DcVoltage,
+				"ground_fault_resistance": t.GroundFaultResistance,
+				"power_limit":             t.PowerLimit,
+				"temperature":             t.Temperature,
+				"operation_mode":          float64(t.OperationMode),
+				"ac_current":              t.L1Data.AcCurrent,
+				"ac_frequency":            t.L1Data.AcFrequency,
+				"ac_voltage":              t.L1Data.AcVoltage,
+				"active_power":            t.L1Data.ActivePower,
+				"apparent_power":          t.L1Data.ApparentPower,
+				"reactive_power":          t.L1Data.ReactivePower,
+				"cos_phi":                 t.L1Data.CosPhi,
+			},
+			Time: t.Time.Local(loc),
+		})
+	}
+	return points
+}
+
+func siteTags(siteID int) map[string]string {
+	return map[string]string{TagSite: strconv.Itoa(siteID)}
+}
+
+func serialTags(siteID int, serialNr string) map[string]string {
+	tags := siteTags(siteID)
+	tags[TagSerial] = serialNr
+	return tags
+}
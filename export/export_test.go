@@ -0,0 +1,103 @@
+package export
+
+import (
+	"github.com/clambin/solaredge/v2"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testTime = time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+func TestValues(t *testing.T) {
+	got := Values(1, MeasurementPower, "power", []solaredge.Value{{Date: solaredge.Time(testTime), Value: 1000}}, nil)
+	want := []Point{{
+		Measurement: MeasurementPower,
+		Tags:        map[string]string{TagSite: "1"},
+		Fields:      map[string]float64{"power": 1000},
+		Time:        testTime,
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestValues_Location(t *testing.T) {
+	brussels, err := time.LoadLocation("Europe/Brussels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the API reports the site's wall clock time: 12:00 in Brussels is 10:00 UTC in summer and 11:00 UTC in winter
+	summer := solaredge.Time(time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC))
+	winter := solaredge.Time(time.Date(2024, time.December, 1, 12, 0, 0, 0, time.UTC))
+	got := Values(1, MeasurementPower, "power", []solaredge.Value{{Date: summer}, {Date: winter}}, brussels)
+	want := []time.Time{
+		time.Date(2024, time.June, 1, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, time.December, 1, 11, 0, 0, 0, time.UTC),
+	}
+	for i := range want {
+		if !got[i].Time.Equal(want[i]) {
+			t.Errorf("point %d: got %v, want %v", i, got[i].Time.UTC(), want[i])
+		}
+	}
+}
+
+func TestMeterReadings(t *testing.T) {
+	readings := []solaredge.MeterReadings{
+		{Type: "Production", Values: []solaredge.Value{{Date: solaredge.Time(testTime), Value: 10}}},
+		{Type: "FeedIn", Values: []solaredge.Value{{Date: solaredge.Time(testTime), Value: 5}}},
+	}
+	tests := []struct {
+		name    string
+		convert func(int, []solaredge.MeterReadings, *time.Location) []Point
+		want    []Point
+	}{
+		{
+			name:    "power",
+			convert: MeterPower,
+			want: []Point{
+				{Measurement: MeasurementMeterPower, Tags: map[string]string{TagSite: "1", TagMeter: "Production"}, Fields: map[string]float64{"power": 10}, Time: testTime},
+				{Measurement: MeasurementMeterPower, Tags: map[string]string{TagSite: "1", TagMeter: "FeedIn"}, Fields: map[string]float64{"power": 5}, Time: testTime},
+			},
+		},
+		{
+			name:    "energy",
+			convert: MeterEnergy,
+			want: []Point{
+				{Measurement: MeasurementMeterEnergy, Tags: map[string]string{TagSite: "1", TagMeter: "Production"}, Fields: map[string]float64{"energy": 10}, Time: testTime},
+				{Measurement: MeasurementMeterEnergy, Tags: map[string]string{TagSite: "1", TagMeter: "FeedIn"}, Fields: map[string]float64{"energy": 5}, Time: testTime},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.convert(1, readings, nil); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatteryTelemetry(t *testing.T) {
+	got := BatteryTelemetry(1, "BAT1", []solaredge.BatteryTelemetry{{TimeStamp: solaredge.Time(testTime), Power: 500, BatteryState: 3}}, nil)
+	if len(got) != 1 {
+		t.Fatalf("got %d points, want 1", len(got))
+	}
+	if got[0].Measurement != MeasurementBattery || got[0].Tags[TagSerial] != "BAT1" || got[0].Fields["power"] != 500 || got[0].Fields["battery_state"] != 3 {
+		t.Errorf("unexpected point: %v", got[0])
+	}
+}
+
+func TestInverterTelemetry(t *testing.T) {
+	got := InverterTelemetry(1, "SN1", []solaredge.InverterTelemetry{{
+		Time:             solaredge.Time(testTime),
+		TotalActivePower: 1000,
+		L1Data:           solaredge.InverterTelemetryL1Data{AcVoltage: 230},
+	}}, nil)
+	if len(got) != 1 {
+		t.Fatalf("got %d points, want 1", len(got))
+	}
+	if got[0].Measurement != MeasurementInverter || got[0].Tags[TagSerial] != "SN1" || got[0].Fields["total_active_power"] != 1000 || got[0].Fields["ac_voltage"] != 230 {
+		t.Errorf("unexpected point: %v", got[0])
+	}
+}
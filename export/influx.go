@@ -0,0 +1,124 @@
+package export
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// WriteLineProtocol writes the points in InfluxDB line protocol. Tags and fields are sorted by key, and timestamps
+// are written in nanoseconds. Line protocol can't represent NaN or infinite values: these fields are skipped, as are
+// points without any other fields.
+func WriteLineProtocol(w io.Writer, points []Point) error {
+	var line []byte
+	for _, p := range points {
+		if !hasFiniteFields(p) {
+			continue
+		}
+		line = appendLine(line[:0], p)
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendLine(b []byte, p Point) []byte {
+	b = append(b, measurementEscaper.Replace(p.Measurement)...)
+	for _, key := range slices.Sorted(maps.Keys(p.Tags)) {
+		if p.Tags[key] == "" {
+			// line protocol doesn't allow empty tag values
+			continue
+		}
+		b = append(b, ',')
+		b = append(b, tagEscaper.Replace(key)...)
+		b = append(b, '=')
+		b = append(b, tagEscaper.Replace(p.Tags[key])...)
+	}
+	sep := byte(' ')
+	for _, key := range slices.Sorted(maps.Keys(p.Fields)) {
+		if !finite(p.Fields[key]) {
+			continue
+		}
+		b = append(b, sep)
+		sep = ','
+		b = append(b, tagEscaper.Replace(key)...)
+		b = append(b, '=')
+		b = strconv.AppendFloat(b, p.Fields[key], 'f', -1, 64)
+	}
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, p.Time.UnixNano(), 10)
+	return append(b, '\n')
+}
+
+func hasFiniteFields(p Point) bool {
+	for _, v := range p.Fields {
+		if finite(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	tagEscaper         = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+)
+
+// InfluxWriter writes Points to an InfluxDB v2 server, using the /api/v2/write endpoint.
+type InfluxWriter struct {
+	HTTPClient *http.Client
+	// URL of the InfluxDB server, e.g. http://localhost:8086.
+	URL          string
+	Organization string
+	Bucket       string
+	Token        string
+}
+
+// Write sends the points to InfluxDB.
+func (w InfluxWriter) Write(ctx context.Context, points []Point) error {
+	var body bytes.Buffer
+	if err := WriteLineProtocol(&body, points); err != nil {
+		return err
+	}
+	args := url.Values{
+		"org":       []string{w.Organization},
+		"bucket":    []string{w.Bucket},
+		"precision": []string{"ns"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(w.URL, "/")+"/api/v2/write?"+args.Encode(), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.Token != "" {
+		req.Header.Set("Authorization", "Token "+w.Token)
+	}
+	return post(cmp.Or(w.HTTPClient, http.DefaultClient), req)
+}
+
+func post(c *http.Client, req *http.Request) error {
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("write: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
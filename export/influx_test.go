@@ -0,0 +1,65 @@
+package export
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteLineProtocol(t *testing.T) {
+	points := []Point{
+		{Measurement: MeasurementPower, Tags: map[string]string{TagSite: "1"}, Fields: map[string]float64{"power": 1000.5}, Time: testTime},
+		{Measurement: MeasurementInverter, Tags: map[string]string{TagSite: "1", TagSerial: "SN 1,2=3", "empty": ""}, Fields: map[string]float64{"temperature": 40, "dc_voltage": 380, "ac_current": math.NaN()}, Time: testTime},
+		{Measurement: MeasurementBattery, Tags: map[string]string{TagSite: "1"}, Fields: map[string]float64{"power": math.Inf(1)}, Time: testTime},
+		{Measurement: "my measurement,x", Fields: map[string]float64{"value": 1}, Time: testTime},
+	}
+	var b strings.Builder
+	if err := WriteLineProtocol(&b, points); err != nil {
+		t.Fatal(err)
+	}
+	want := `solaredge_power,site=1 power=1000.5 1717243200000000000
+solaredge_inverter,serial=SN\ 1\,2\=3,site=1 dc_voltage=380,temperature=40 1717243200000000000
+my\ measurement\,x value=1 1717243200000000000
+`
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestInfluxWriter_Write(t *testing.T) {
+	var body, query, auth string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/write" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		body, query, auth = string(b), r.URL.RawQuery, r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer s.Close()
+
+	w := InfluxWriter{URL: s.URL + "/", Organization: "org", Bucket: "solar", Token: "secret"}
+	points := []Point{{Measurement: MeasurementPower, Tags: map[string]string{TagSite: "1"}, Fields: map[string]float64{"power": 1000}, Time: testTime}}
+	if err := w.Write(context.Background(), points); err != nil {
+		t.Fatal(err)
+	}
+	if body != "solaredge_power,site=1 power=1000 1717243200000000000\n" {
+		t.Errorf("unexpected body: %q", body)
+	}
+	if query != "bucket=solar&org=org&precision=ns" {
+		t.Errorf("unexpected query: %q", query)
+	}
+	if auth != "Token secret" {
+		t.Errorf("unexpected authorization: %q", auth)
+	}
+
+	w.URL = s.URL + "/invalid"
+	if err := w.Write(context.Background(), points); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected a 404 error, got %v", err)
+	}
+}
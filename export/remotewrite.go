@@ -0,0 +1,170 @@
+package export
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// TimeSeries is a Prometheus time series: a set of labels (including the metric name) and its samples.
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// Label is a Prometheus label.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a Prometheus sample. Timestamp is expressed in milliseconds since the epoch.
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// ToTimeSeries converts Points into Prometheus time series. Each field of a Point becomes a separate series,
+// named after the measurement and the field (e.g. solaredge_inverter_temperature). If the measurement's name already
+// ends in the field's name, the measurement's name is used (e.g. solaredge_power). Tags become labels.
+// Series are sorted by name and labels; samples within a series are sorted by timestamp. NaN and infinite values
+// are skipped.
+func ToTimeSeries(points []Point) []TimeSeries {
+	series := make(map[string]*TimeSeries)
+	for _, p := range points {
+		for field, value := range p.Fields {
+			if !finite(value) {
+				continue
+			}
+			labels := make([]Label, 0, len(p.Tags)+1)
+			labels = append(labels, Label{Name: "__name__", Value: metricName(p.Measurement, field)})
+			for name, value := range p.Tags {
+				labels = append(labels, Label{Name: name, Value: value})
+			}
+			slices.SortFunc(labels, func(a, b Label) int { return strings.Compare(a.Name, b.Name) })
+
+			id := seriesID(labels)
+			s, ok := series[id]
+			if !ok {
+				s = &TimeSeries{Labels: labels}
+				series[id] = s
+			}
+			s.Samples = append(s.Samples, Sample{Value: value, Timestamp: p.Time.UnixMilli()})
+		}
+	}
+
+	result := make([]TimeSeries, 0, len(series))
+	for _, id := range slices.Sorted(maps.Keys(series)) {
+		s := series[id]
+		slices.SortFunc(s.Samples, func(a, b Sample) int { return cmp.Compare(a.Timestamp, b.Timestamp) })
+		result = append(result, *s)
+	}
+	return result
+}
+
+func metricName(measurement, field string) string {
+	if strings.HasSuffix(measurement, "_"+field) {
+		return measurement
+	}
+	return measurement + "_" + field
+}
+
+func seriesID(labels []Label) string {
+	var b strings.Builder
+	for _, l := range labels {
+		b.WriteString(l.Name)
+		b.WriteByte(0)
+		b.WriteString(l.Value)
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+// EncodeWriteRequest returns the protobuf encoding of a Prometheus remote write (v1) WriteRequest holding the series.
+func EncodeWriteRequest(series []TimeSeries) []byte {
+	var b []byte
+	for _, s := range series {
+		b = appendMessage(b, 1, encodeTimeSeries(s))
+	}
+	return b
+}
+
+func encodeTimeSeries(s TimeSeries) []byte {
+	var b []byte
+	for _, l := range s.Labels {
+		var label []byte
+		label = appendString(label, 1, l.Name)
+		label = appendString(label, 2, l.Value)
+		b = appendMessage(b, 1, label)
+	}
+	for _, sample := range s.Samples {
+		var msg []byte
+		msg = binary.AppendUvarint(msg, 1<<3|1) // field 1, fixed64
+		msg = binary.LittleEndian.AppendUint64(msg, math.Float64bits(sample.Value))
+		msg = binary.AppendUvarint(msg, 2<<3|0) // field 2, varint
+		msg = binary.AppendUvarint(msg, uint64(sample.Timestamp))
+		b = appendMessage(b, 2, msg)
+	}
+	return b
+}
+
+func appendString(b []byte, field int, s string) []byte {
+	return appendMessage(b, field, []byte(s))
+}
+
+// appendMessage appends a length-delimited field.
+func appendMessage(b []byte, field int, msg []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(msg)))
+	return append(b, msg...)
+}
+
+// snappyEncode returns the snappy block encoding of src. The encoding only uses literals: the result is valid snappy,
+// but not compressed. The samples sent by an exporter are small enough for this not to matter.
+func snappyEncode(src []byte) []byte {
+	const maxLiteral = 1 << 16
+	b := binary.AppendUvarint(nil, uint64(len(src)))
+	for len(src) > 0 {
+		n := min(len(src), maxLiteral)
+		if n <= 60 {
+			b = append(b, byte(n-1)<<2)
+		} else {
+			// tag 61: length-1 is stored in the next two bytes
+			b = append(b, 61<<2, byte(n-1), byte((n-1)>>8))
+		}
+		b = append(b, src[:n]...)
+		src = src[n:]
+	}
+	return b
+}
+
+// RemoteWriter writes Points to a Prometheus remote write endpoint.
+type RemoteWriter struct {
+	HTTPClient *http.Client
+	// URL of the remote write endpoint, e.g. http://localhost:9090/api/v1/write.
+	URL string
+	// Headers are added to each request, e.g. for authentication.
+	Headers http.Header
+}
+
+// Write sends the points to the remote write endpoint.
+func (w RemoteWriter) Write(ctx context.Context, points []Point) error {
+	body := snappyEncode(EncodeWriteRequest(ToTimeSeries(points)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for key, values := range w.Headers {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	return post(cmp.Or(w.HTTPClient, http.DefaultClient), req)
+}
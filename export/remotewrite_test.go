@@ -0,0 +1,149 @@
+package export
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToTimeSeries(t *testing.T) {
+	points := []Point{
+		{Measurement: MeasurementPower, Tags: map[string]string{TagSite: "1"}, Fields: map[string]float64{"power": 200}, Time: testTime.Add(time.Minute)},
+		{Measurement: MeasurementPower, Tags: map[string]string{TagSite: "1"}, Fields: map[string]float64{"power": 100}, Time: testTime},
+		{Measurement: MeasurementInverter, Tags: map[string]string{TagSite: "1", TagSerial: "SN1"}, Fields: map[string]float64{"temperature": 40, "dc_voltage": math.NaN()}, Time: testTime},
+		{Measurement: MeasurementBattery, Tags: map[string]string{TagSite: "1"}, Fields: map[string]float64{"power": math.Inf(-1)}, Time: testTime},
+	}
+	want := []TimeSeries{
+		{
+			Labels:  []Label{{Name: "__name__", Value: "solaredge_inverter_temperature"}, {Name: TagSerial, Value: "SN1"}, {Name: TagSite, Value: "1"}},
+			Samples: []Sample{{Value: 40, Timestamp: testTime.UnixMilli()}},
+		},
+		{
+			Labels:  []Label{{Name: "__name__", Value: "solaredge_power"}, {Name: TagSite, Value: "1"}},
+			Samples: []Sample{{Value: 100, Timestamp: testTime.UnixMilli()}, {Value: 200, Timestamp: testTime.Add(time.Minute).UnixMilli()}},
+		},
+	}
+	if got := ToTimeSeries(points); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRemoteWriter_Write(t *testing.T) {
+	var got []TimeSeries
+	var headers http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		headers = r.Header
+		msg, err := snappyDecode(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		got = decodeWriteRequest(t, msg)
+	}))
+	defer s.Close()
+
+	// enough series to need more than one snappy literal
+	var points []Point
+	for i := range 100 {
+		points = append(points, Point{
+			Measurement: MeasurementInverter,
+			Tags:        map[string]string{TagSite: "1", TagSerial: strings.Repeat("X", i)},
+			Fields:      map[string]float64{"temperature": float64(i)},
+			Time:        testTime,
+		})
+	}
+
+	w := RemoteWriter{URL: s.URL, Headers: http.Header{"Authorization": []string{"Bearer secret"}}}
+	if err := w.Write(context.Background(), points); err != nil {
+		t.Fatal(err)
+	}
+	if want := ToTimeSeries(points); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for key, value := range map[string]string{"Authorization": "Bearer secret", "Content-Encoding": "snappy", "Content-Type": "application/x-protobuf"} {
+		if headers.Get(key) != value {
+			t.Errorf("%s: got %q, want %q", key, headers.Get(key), value)
+		}
+	}
+}
+
+// snappyDecode decodes a snappy block that only contains literals.
+func snappyDecode(src []byte) ([]byte, error) {
+	size, n := binary.Uvarint(src)
+	src = src[n:]
+	dst := make([]byte, 0, size)
+	for len(src) > 0 {
+		tag := src[0]
+		if tag&3 != 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		length := int(tag>>2) + 1
+		src = src[1:]
+		if tag>>2 == 61 {
+			length = int(binary.LittleEndian.Uint16(src)) + 1
+			src = src[2:]
+		}
+		dst = append(dst, src[:length]...)
+		src = src[length:]
+	}
+	return dst, nil
+}
+
+func decodeWriteRequest(t *testing.T, b []byte) []TimeSeries {
+	t.Helper()
+	var series []TimeSeries
+	for field, msg := range protoFields(t, b) {
+		if field != 1 {
+			t.Fatalf("unexpected field %d in WriteRequest", field)
+		}
+		var s TimeSeries
+		for field, msg := range protoFields(t, msg) {
+			switch field {
+			case 1:
+				var l Label
+				for field, value := range protoFields(t, msg) {
+					if field == 1 {
+						l.Name = string(value)
+					} else {
+						l.Value = string(value)
+					}
+				}
+				s.Labels = append(s.Labels, l)
+			case 2:
+				var sample Sample
+				sample.Value = math.Float64frombits(binary.LittleEndian.Uint64(msg[1:9]))
+				ts, _ := binary.Uvarint(msg[10:])
+				sample.Timestamp = int64(ts)
+				s.Samples = append(s.Samples, sample)
+			}
+		}
+		series = append(series, s)
+	}
+	return series
+}
+
+// protoFields iterates over the length-delimited fields of a protobuf message.
+func protoFields(t *testing.T, b []byte) func(func(int, []byte) bool) {
+	return func(yield func(int, []byte) bool) {
+		for len(b) > 0 {
+			key, n := binary.Uvarint(b)
+			if key&7 != 2 {
+				t.Fatalf("unexpected wire type %d", key&7)
+			}
+			length, m := binary.Uvarint(b[n:])
+			msg := b[n+m : n+m+int(length)]
+			b = b[n+m+int(length):]
+			if !yield(int(key>>3), msg) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package solaredge
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// HealthStatus is the outcome of a health check.
+type HealthStatus int
+
+const (
+	HealthPass HealthStatus = iota
+	HealthWarn
+	HealthFail
+)
+
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthPass:
+		return "pass"
+	case HealthWarn:
+		return "warn"
+	case HealthFail:
+		return "fail"
+	default:
+		return fmt.Sprintf("health(%d)", int(s))
+	}
+}
+
+// HealthCheck is the outcome of one of the checks performed by SiteHealth.
+type HealthCheck struct {
+	Name    string
+	Message string
+	Status  HealthStatus
+}
+
+// Names of the checks performed by SiteHealth.
+const (
+	CheckStatus        = "status"
+	CheckCommunication = "communication"
+	CheckDataPeriod    = "data_period"
+	CheckInverters     = "inverters"
+	CheckEquipment     = "equipment_changes"
+	CheckBattery       = "battery"
+	CheckTimeZone      = "time_zone"
+)
+
+// SiteHealth is a summary of a site's health, as returned by Client.SiteHealth.
+type SiteHealth struct {
+	// LastUpdate is the time the site last reported to the monitoring platform, in the site's time zone.
+	LastUpdate time.Time
+	// DataPeriod is the period for which the site has data.
+	DataPeriod DataPeriod
+	// Status is the site's status, as reported in its details (e.g. "Active").
+	Status string
+	// RecentChanges holds the equipment replacements of the site's inverters during the last 30 days.
+	RecentChanges []EquipmentChangeLog
+	// Checks holds the outcome of each check.
+	Checks []HealthCheck
+	// Staleness is the time since the site last reported.
+	Staleness time.Duration
+	SiteID    int
+	// SilentInverters holds the serial numbers of the inverters that didn't report technical data during the last day.
+	SilentInverters []string
+	// UnreachableInverters holds the serial numbers of the inverters whose technical data couldn't be retrieved.
+	UnreachableInverters []string
+	// Inverters is the number of inverters in the site's inventory. ReportingInverters is the number of inverters
+	// that reported technical data during the last day.
+	Inverters          int
+	ReportingInverters int
+	// BatteryCritical is true if the site's battery is in a critical state.
+	BatteryCritical bool
+	// Verdict is the worst status of the Checks.
+	Verdict HealthStatus
+}
+
+// Thresholds used by SiteHealth. Inverters stop reporting at night, so communication is only considered stale after
+// a full day.
+const (
+	staleWarning        = 24 * time.Hour
+	staleFailure        = 3 * 24 * time.Hour
+	recentChangesPeriod = 30 * 24 * time.Hour
+)
+
+// SiteHealth checks the health of a site and returns a summary. It checks that:
+//
+//   - the site is active
+//   - the site reported during the last day (warn) or last 3 days (fail)
+//   - the site has data up to yesterday
+//   - all inverters in the inventory reported technical data during the last day (warn if some didn't, fail if none did).
+//     Inverters whose technical data can't be retrieved count as not reporting.
+//   - the inverters' equipment wasn't replaced during the last 30 days
+//   - the battery is not in a critical state. This check is skipped for sites without batteries in their inventory.
+//
+// Each check results in a HealthCheck. If a call needed for a check fails, the check fails with the error as its
+// message. If the site's time zone is invalid, SiteHealth adds a warning (CheckTimeZone) and compares times in UTC.
+// SiteHealth only returns an error if the site's details can't be retrieved.
+//
+// SiteHealth calls the API once for each of GetSiteDetails, GetPowerOverview, GetDataPeriod and GetInventory,
+// once per inverter for GetInverterTechnicalData and GetEquipmentChangeLog and, if the site has batteries, once for
+// GetPowerFlow. A site with n inverters therefore uses 4 + 2n requests of the daily quota, plus one with batteries.
+// The GetInverterTechnicalData calls are needed to count the reporting inverters.
+func (c *Client) SiteHealth(ctx context.Context, id int) (SiteHealth, error) {
+	return siteHealth(ctx, c, id, time.Now())
+}
+
+// SiteHealth calls Client.SiteHealth for the site.
+func (s *SiteClient) SiteHealth(ctx context.Context) (SiteHealth, error) {
+	return s.client.SiteHealth(ctx, s.id)
+}
+
+// SiteHealth performs Client.SiteHealth with the Pool's Clients.
+func (p *Pool) SiteHealth(ctx context.Context, id int) (SiteHealth, error) {
+	return siteHealth(ctx, p, id, time.Now())
+}
+
+func siteHealth(ctx context.Context, api API, id int, now time.Time) (SiteHealth, error) {
+	health := SiteHealth{SiteID: id}
+	details, err := api.GetSiteDetails(ctx, id)
+	if err != nil {
+		return health, err
+	}
+	health.Status = details.Details.Status
+	health.add(statusCheck(details.Details.Status))
+
+	// the API reports the site's local time: convert now to the site's time zone to compare
+	location, err := time.LoadLocation(details.Details.Location.TimeZone)
+	if err != nil {
+		location = time.UTC
+		health.add(HealthCheck{Name: CheckTimeZone, Status: HealthWarn, Message: fmt.Sprintf("invalid time zone, using UTC: %s", err)})
+	}
+	local := now.In(location)
+	siteNow := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.UTC)
+
+	if overview, err := api.GetPowerOverview(ctx, id); err == nil {
+		lastUpdate := time.Time(overview.Overview.LastUpdateTime)
+		health.LastUpdate = time.Date(lastUpdate.Year(), lastUpdate.Month(), lastUpdate.Day(), lastUpdate.Hour(), lastUpdate.Minute(), lastUpdate.Second(), 0, location)
+		health.Staleness = max(0, siteNow.Sub(lastUpdate))
+		health.add(stalenessCheck(health.Staleness))
+	} else {
+		health.add(failed(CheckCommunication, err))
+	}
+
+	if dataPeriod, err := api.GetDataPeriod(ctx, id); err == nil {
+		health.DataPeriod = dataPeriod.DataPeriod
+		health.add(dataPeriodCheck(dataPeriod.DataPeriod, siteNow))
+	} else {
+		health.add(failed(CheckDataPeriod, err))
+	}
+
+	inventory, err := api.GetInventory(ctx, id)
+	if err != nil {
+		health.add(failed(CheckInverters, err))
+		health.add(failed(CheckEquipment, err))
+		health.add(failed(CheckBattery, err))
+		return health, nil
+	}
+	health.Inverters = len(inventory.Inventory.Inverters)
+	health.add(health.reportingInverters(ctx, api, inventory.Inventory.Inverters, siteNow))
+	health.add(health.recentChanges(ctx, api, inventory.Inventory.Inverters, siteNow))
+
+	if len(inventory.Inventory.Batteries) > 0 {
+		if flow, err := api.GetPowerFlow(ctx, id); err == nil {
+			health.BatteryCritical = flow.CurrentPowerFlow.Storage.Critical
+			health.add(batteryCheck(health.BatteryCritical))
+		} else {
+			health.add(failed(CheckBattery, err))
+		}
+	}
+	return health, nil
+}
+
+// add records the outcome of a check and updates the verdict.
+func (h *SiteHealth) add(check HealthCheck) {
+	h.Checks = append(h.Checks, check)
+	h.Verdict = max(h.Verdict, check.Status)
+}
+
+func failed(name string, err error) HealthCheck {
+	return HealthCheck{Name: name, Status: HealthFail, Message: err.Error()}
+}
+
+func statusCheck(status string) HealthCheck {
+	if status != "Active" {
+		return HealthCheck{Name: CheckStatus, Status: HealthFail, Message: fmt.Sprintf("site is %q", status)}
+	}
+	return HealthCheck{Name: CheckStatus, Status: HealthPass, Message: "site is active"}
+}
+
+func stalenessCheck(staleness time.Duration) HealthCheck {
+	check := HealthCheck{Name: CheckCommunication, Message: fmt.Sprintf("last update %s ago", staleness.Round(time.Minute))}
+	switch {
+	case staleness > staleFailure:
+		check.Status = HealthFail
+	case staleness > staleWarning:
+		check.Status = HealthWarn
+	}
+	return check
+}
+
+func dataPeriodCheck(period DataPeriod, siteNow time.Time) HealthCheck {
+	end := time.Time(period.EndDate)
+	check := HealthCheck{Name: CheckDataPeriod, Message: "data until " + end.Format(time.DateOnly)}
+	if yesterday := siteNow.Truncate(24*time.Hour).AddDate(0, 0, -1); end.Before(yesterday) {
+		check.Status = HealthWarn
+	}
+	return check
+}
+
+// reportingInverters records which inverters reported technical data during the last day. Inverters also report
+// while sleeping, so a day without data means the inverter stopped communicating. If the technical data of an
+// inverter can't be retrieved, the inverter is recorded as unreachable and the check continues with the next one.
+func (h *SiteHealth) reportingInverters(ctx context.Context, api API, inverters []InverterEquipment, siteNow time.Time) HealthCheck {
+	var firstErr error
+	for _, inverter := range inverters {
+		data, err := api.GetInverterTechnicalData(ctx, h.SiteID, inverter.SN, siteNow.Add(-staleWarning), siteNow)
+		switch {
+		case err != nil:
+			h.UnreachableInverters = append(h.UnreachableInverters, inverter.SN)
+			if firstErr == nil {
+				firstErr = err
+			}
+		case len(data.Data.Telemetries) > 0:
+			h.ReportingInverters++
+		default:
+			h.SilentInverters = append(h.SilentInverters, inverter.SN)
+		}
+	}
+	check := HealthCheck{Name: CheckInverters, Message: fmt.Sprintf("%d of %d inverters reporting", h.ReportingInverters, h.Inverters)}
+	if firstErr != nil {
+		check.Message += fmt.Sprintf(", %d unreachable: %s", len(h.UnreachableInverters), firstErr)
+	}
+	switch {
+	case h.ReportingInverters == 0 && h.Inverters > 0:
+		check.Status = HealthFail
+	case h.ReportingInverters < h.Inverters:
+		check.Status = HealthWarn
+	}
+	return check
+}
+
+func batteryCheck(critical bool) HealthCheck {
+	if critical {
+		return HealthCheck{Name: CheckBattery, Status: HealthFail, Message: "battery is critical"}
+	}
+	return HealthCheck{Name: CheckBattery, Status: HealthPass, Message: "battery is not critical"}
+}
+
+// recentChanges records the inverters' equipment replacements during the last 30 days. Changes with a date that
+// can't be parsed are considered recent.
+func (h *SiteHealth) recentChanges(ctx context.Context, api API, inverters []InverterEquipment, siteNow time.Time) HealthCheck {
+	since := siteNow.Add(-recentChangesPeriod)
+	for _, inverter := range inverters {
+		changeLog, err := api.GetEquipmentChangeLog(ctx, h.SiteID, inverter.SN)
+		if err != nil {
+			return failed(CheckEquipment, err)
+		}
+		for _, change := range changeLog.ChangeLog.List {
+			if date, err := time.Parse(time.DateOnly, change.Date); err != nil || !date.Before(since) {
+				h.RecentChanges = append(h.RecentChanges, change)
+			}
+		}
+	}
+	if len(h.RecentChanges) > 0 {
+		return HealthCheck{Name: CheckEquipment, Status: HealthWarn, Message: fmt.Sprintf("%d equipment changes during the last 30 days", len(h.RecentChanges))}
+	}
+	return HealthCheck{Name: CheckEquipment, Status: HealthPass, Message: "no recent equipment changes"}
+}
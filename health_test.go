@@ -0,0 +1,132 @@
+package solaredge_test
+
+import (
+	"context"
+	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/solaredgetest"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClient_SiteHealth(t *testing.T) {
+	var details solaredge.SiteDetails
+	details.Id = 1
+	details.Status = "Active"
+	details.Location.TimeZone = "Europe/Brussels"
+	s := solaredgetest.NewServer("KEY", solaredgetest.Site{
+		Details: details,
+		Inventory: solaredge.Inventory{
+			Inverters: []solaredge.InverterEquipment{{SN: "SN1"}, {SN: "SN2"}},
+			Batteries: []solaredge.BatteryEquipment{{SN: "BAT1"}},
+		},
+	})
+	defer s.Close()
+	c := solaredge.Client{SiteKey: "KEY", HTTPClient: s.Client()}
+	ctx := context.Background()
+
+	health, err := c.Site(1).SiteHealth(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Verdict != solaredge.HealthPass {
+		t.Errorf("got verdict %s, want %s: %+v", health.Verdict, solaredge.HealthPass, health.Checks)
+	}
+	if len(health.Checks) != 6 {
+		t.Errorf("got %d checks, want 6", len(health.Checks))
+	}
+	if health.Inverters != 2 || health.ReportingInverters != 2 {
+		t.Errorf("got %d of %d inverters reporting, want 2 of 2", health.ReportingInverters, health.Inverters)
+	}
+	if health.Staleness > time.Minute {
+		t.Errorf("got staleness %s, want less than a minute", health.Staleness)
+	}
+
+	// the site stopped reporting two days ago and the power flow fails
+	s.Now = func() time.Time { return time.Now().Add(-48 * time.Hour) }
+	s.Fail("/site/1/currentPowerFlow", solaredgetest.Failure{StatusCode: http.StatusInternalServerError, Message: "internal error"})
+	if health, err = c.SiteHealth(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]solaredge.HealthStatus{
+		solaredge.CheckStatus:        solaredge.HealthPass,
+		solaredge.CheckCommunication: solaredge.HealthWarn,
+		solaredge.CheckDataPeriod:    solaredge.HealthWarn,
+		solaredge.CheckInverters:     solaredge.HealthFail,
+		solaredge.CheckEquipment:     solaredge.HealthPass,
+		solaredge.CheckBattery:       solaredge.HealthFail,
+	}
+	for _, check := range health.Checks {
+		if check.Status != want[check.Name] {
+			t.Errorf("%s: got %s, want %s (%s)", check.Name, check.Status, want[check.Name], check.Message)
+		}
+	}
+	if health.Verdict != solaredge.HealthFail {
+		t.Errorf("got verdict %s, want %s", health.Verdict, solaredge.HealthFail)
+	}
+
+	if _, err = c.SiteHealth(ctx, 2); err == nil {
+		t.Error("expected an error for an unknown site")
+	}
+}
+
+func TestClient_SiteHealth_SilentInverter(t *testing.T) {
+	var details solaredge.SiteDetails
+	details.Id = 1
+	details.Status = "Active"
+	s := solaredgetest.NewServer("KEY", solaredgetest.Site{
+		Details:         details,
+		Inventory:       solaredge.Inventory{Inverters: []solaredge.InverterEquipment{{SN: "SN1"}, {SN: "SN2"}}},
+		SilentInverters: []string{"SN2"},
+	})
+	defer s.Close()
+	c := solaredge.Client{SiteKey: "KEY", HTTPClient: s.Client()}
+
+	health, err := c.SiteHealth(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.ReportingInverters != 1 || !slices.Equal(health.SilentInverters, []string{"SN2"}) {
+		t.Errorf("got %d of %d inverters reporting, silent: %v", health.ReportingInverters, health.Inverters, health.SilentInverters)
+	}
+	if health.Verdict != solaredge.HealthWarn {
+		t.Errorf("got verdict %s, want %s: %+v", health.Verdict, solaredge.HealthWarn, health.Checks)
+	}
+	// the site has no battery: the battery check is skipped
+	for _, check := range health.Checks {
+		if check.Name == solaredge.CheckBattery {
+			t.Errorf("unexpected battery check: %+v", check)
+		}
+	}
+
+	// the technical data of the other inverter can't be retrieved: the check records it and continues
+	s.Fail("/equipment/1/SN1/data", solaredgetest.Failure{StatusCode: http.StatusInternalServerError})
+	if health, err = c.SiteHealth(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if health.ReportingInverters != 0 || !slices.Equal(health.UnreachableInverters, []string{"SN1"}) || !slices.Equal(health.SilentInverters, []string{"SN2"}) {
+		t.Errorf("got %d reporting, unreachable: %v, silent: %v", health.ReportingInverters, health.UnreachableInverters, health.SilentInverters)
+	}
+	if health.Verdict != solaredge.HealthFail {
+		t.Errorf("got verdict %s, want %s: %+v", health.Verdict, solaredge.HealthFail, health.Checks)
+	}
+}
+
+func TestClient_SiteHealth_InvalidTimeZone(t *testing.T) {
+	var details solaredge.SiteDetails
+	details.Id = 1
+	details.Status = "Active"
+	details.Location.TimeZone = "Nowhere/Invalid"
+	s := solaredgetest.NewServer("KEY", solaredgetest.Site{Details: details})
+	defer s.Close()
+	c := solaredge.Client{SiteKey: "KEY", HTTPClient: s.Client()}
+
+	health, err := c.SiteHealth(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := slices.IndexFunc(health.Checks, func(c solaredge.HealthCheck) bool { return c.Name == solaredge.CheckTimeZone }); i < 0 || health.Checks[i].Status != solaredge.HealthWarn {
+		t.Errorf("expected a time zone warning: %+v", health.Checks)
+	}
+}
@@ -0,0 +1,94 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/clambin/solaredge/v2/internal/atomicfile"
+	"github.com/clambin/solaredge/v2/store"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"sync"
+	"time"
+)
+
+// Checkpoints records how far each series has been synced. Series are identified by a store.Key, with the Series as
+// its Type.
+type Checkpoints interface {
+	// Get returns the time up to which the series has been synced. Returns false if the series has not been synced yet.
+	Get(key store.Key) (time.Time, bool)
+	// Set records the time up to which the series has been synced.
+	Set(key store.Key, t time.Time) error
+}
+
+var (
+	_ Checkpoints = &FileCheckpoints{}
+	_ Checkpoints = memoryCheckpoints{}
+)
+
+// memoryCheckpoints keeps checkpoints in memory. Used by a Syncer without Checkpoints.
+type memoryCheckpoints map[string]time.Time
+
+func (m memoryCheckpoints) Get(key store.Key) (time.Time, bool) {
+	t, ok := m[key.String()]
+	return t, ok
+}
+
+func (m memoryCheckpoints) Set(key store.Key, t time.Time) error {
+	m[key.String()] = t
+	return nil
+}
+
+// FileCheckpoints stores checkpoints in a JSON file. The file is rewritten each time a checkpoint is set, so progress
+// survives an interruption of the sync.
+type FileCheckpoints struct {
+	checkpoints map[string]time.Time
+	path        string
+	lock        sync.RWMutex
+}
+
+// OpenCheckpoints returns FileCheckpoints stored in the specified file. If the file doesn't exist, it is created
+// when the first checkpoint is set.
+func OpenCheckpoints(path string) (*FileCheckpoints, error) {
+	c := FileCheckpoints{path: path, checkpoints: make(map[string]time.Time)}
+	body, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(body, &c.checkpoints); err != nil {
+		return nil, fmt.Errorf("checkpoints %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+func (c *FileCheckpoints) Get(key store.Key) (time.Time, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	t, ok := c.checkpoints[key.String()]
+	return t, ok
+}
+
+func (c *FileCheckpoints) Set(key store.Key, t time.Time) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// only update the checkpoints once the file is written, so they always match the file's content
+	checkpoints := maps.Clone(c.checkpoints)
+	checkpoints[key.String()] = t
+	body, err := json.MarshalIndent(checkpoints, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = atomicfile.WriteFile(c.path, func(w io.Writer) error {
+		_, err := w.Write(body)
+		return err
+	}); err != nil {
+		return err
+	}
+	c.checkpoints = checkpoints
+	return nil
+}
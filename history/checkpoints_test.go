@@ -0,0 +1,70 @@
+package history
+
+import (
+	"github.com/clambin/solaredge/v2/store"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileCheckpoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+	c, err := OpenCheckpoints(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := store.Key{SiteID: 1, Type: string(SeriesInverter), Name: "SN1"}
+	if _, ok := c.Get(key); ok {
+		t.Fatal("unexpected checkpoint")
+	}
+	ts := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	if err = c.Set(key, ts); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = OpenCheckpoints(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := c.Get(key); !ok || !got.Equal(ts) {
+		t.Errorf("got %v (%v), want %v", got, ok, ts)
+	}
+	if _, ok := c.Get(store.Key{SiteID: 1, Type: string(SeriesInverter), Name: "SN2"}); ok {
+		t.Error("unexpected checkpoint")
+	}
+
+	if err = os.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = OpenCheckpoints(path); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestFileCheckpoints_FailedWrite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "checkpoints")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	c, err := OpenCheckpoints(filepath.Join(dir, "checkpoints.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := store.Key{SiteID: 1, Type: string(SeriesPower)}
+	ts := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	if err = c.Set(key, ts); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file can't be written: the checkpoint keeps its previous value
+	if err = os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Set(key, ts.Add(time.Hour)); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got, ok := c.Get(key); !ok || !got.Equal(ts) {
+		t.Errorf("got %v (%v), want %v", got, ok, ts)
+	}
+}
@@ -0,0 +1,248 @@
+/*
+Package history downloads the measurement history of a site, from the start of its production up to today.
+
+The SolarEdge API limits the time range of each request, as well as the number of requests per day. A Syncer therefore
+splits the history into windows that comply with the API's limits, and records its progress in Checkpoints after each
+window. When a sync is interrupted (e.g. because the daily quota is exhausted), the next sync resumes where the
+previous one stopped. Once a site's history has been synced, subsequent syncs only download new data.
+
+The downloaded data is passed to a Sink. Use StoreSink to persist it in a store.Store.
+*/
+package history
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/store"
+	"time"
+)
+
+// Series is a type of measurement downloaded by a Syncer.
+type Series string
+
+const (
+	// SeriesPower contains the site's power measurements, as returned by GetPowerMeasurements.
+	SeriesPower Series = "power"
+	// SeriesEnergyDetails contains the site's meter readings, at TimeUnitQuarter resolution, as returned by GetEnergyDetails.
+	SeriesEnergyDetails Series = "energyDetails"
+	// SeriesStorage contains the site's battery telemetry, as returned by GetStorageData.
+	SeriesStorage Series = "storage"
+	// SeriesInverter contains the technical data of each of the site's inverters, as returned by GetInverterTechnicalData.
+	SeriesInverter Series = "inverter"
+)
+
+// AllSeries contains all supported series.
+var AllSeries = []Series{SeriesPower, SeriesEnergyDetails, SeriesStorage, SeriesInverter}
+
+// window returns the end of the largest time range, starting at start, that the API accepts for the series.
+func (s Series) window(start time.Time) time.Time {
+	switch s {
+	case SeriesStorage, SeriesInverter:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// Batch contains the data downloaded for one window of a series. Only the field corresponding to the Series is set.
+type Batch struct {
+	Start time.Time
+	End   time.Time
+	// Key identifies the series. Its Type is the Series.
+	Key       store.Key
+	Power     []solaredge.Value
+	Meters    []solaredge.MeterReadings
+	Batteries []solaredge.Battery
+	Inverter  []solaredge.InverterTelemetry
+}
+
+// A Sink persists the data downloaded by a Syncer.
+//
+// Windows may overlap with data written in a previous sync: the last day of a series is downloaded again on the next
+// sync, as it may have been incomplete. Sinks should therefore overwrite existing measurements with the same timestamp.
+type Sink interface {
+	Write(ctx context.Context, batch Batch) error
+}
+
+// SinkFunc is an adapter that allows the use of an ordinary function as a Sink.
+type SinkFunc func(ctx context.Context, batch Batch) error
+
+func (f SinkFunc) Write(ctx context.Context, batch Batch) error {
+	return f(ctx, batch)
+}
+
+// API is the subset of solaredge.Client's methods used by a Syncer.
+type API interface {
+	GetDataPeriod(ctx context.Context, id int) (solaredge.GetDataPeriodResponse, error)
+	GetComponents(ctx context.Context, id int) (solaredge.GetComponentsResponse, error)
+	GetPowerMeasurements(ctx context.Context, id int, startTime, endTime time.Time) (solaredge.GetPowerMeasurementsResponse, error)
+	GetEnergyDetails(ctx context.Context, id int, timeUnit solaredge.TimeUnit, startTime, endTime time.Time) (solaredge.GetEnergyDetailsResponse, error)
+	GetStorageData(ctx context.Context, id int, startTime, endTime time.Time) (solaredge.GetStorageDataResponse, error)
+	GetInverterTechnicalData(ctx context.Context, id int, serialNr string, startTime, endTime time.Time) (solaredge.GetInverterTechnicalDataResponse, error)
+}
+
+var _ API = &solaredge.Client{}
+
+// ErrBudgetExhausted is returned by Sync when the Syncer has performed MaxRequests requests.
+var ErrBudgetExhausted = errors.New("request budget exhausted")
+
+// A Syncer downloads the history of a site.
+type Syncer struct {
+	API  API
+	Sink Sink
+	// Checkpoints records the progress of the sync. If nil, progress is only kept for the duration of a call to
+	// Sync, so each call downloads the full history.
+	Checkpoints Checkpoints
+	// Series to download. Defaults to AllSeries.
+	Series []Series
+	// MaxRequests is the maximum number of requests performed by each call to Sync. Zero means no limit.
+	// Use this to leave part of the daily quota for other applications.
+	MaxRequests int
+}
+
+// Sync downloads all data of the site that has not been downloaded yet.
+//
+// Sync stops at the first error, after recording the progress made up to that point. Calling Sync again resumes the
+// download. If the Syncer performed MaxRequests requests, Sync returns ErrBudgetExhausted.
+//
+// To let other calls go first when the Client is shared, pass a context with solaredge.PriorityBackground and
+// configure the Client with a solaredge.PriorityLimiter:
+//
+//	ctx = solaredge.WithCallOptions(ctx, solaredge.CallPriority(solaredge.PriorityBackground))
+func (s *Syncer) Sync(ctx context.Context, id int) error {
+	r := run{Syncer: s, id: id, checkpoints: s.Checkpoints}
+	if r.checkpoints == nil {
+		r.checkpoints = make(memoryCheckpoints)
+	}
+
+	dataPeriod, err := r.dataPeriod(ctx)
+	if err != nil {
+		return err
+	}
+	// the API reports the last day with data. Sync up to the end of that day.
+	start := time.Time(dataPeriod.StartDate)
+	end := time.Time(dataPeriod.EndDate).AddDate(0, 0, 1)
+
+	series := s.Series
+	if len(series) == 0 {
+		series = AllSeries
+	}
+	for _, t := range series {
+		if t == SeriesInverter {
+			err = r.syncInverters(ctx, start, end)
+		} else {
+			err = r.syncSeries(ctx, store.Key{SiteID: id, Type: string(t)}, start, end)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run holds the state of a single call to Syncer.Sync.
+type run struct {
+	*Syncer
+	checkpoints Checkpoints
+	id          int
+	requests    int
+}
+
+func (r *run) request() error {
+	if r.MaxRequests > 0 && r.requests >= r.MaxRequests {
+		return ErrBudgetExhausted
+	}
+	r.requests++
+	return nil
+}
+
+func (r *run) dataPeriod(ctx context.Context) (solaredge.DataPeriod, error) {
+	if err := r.request(); err != nil {
+		return solaredge.DataPeriod{}, err
+	}
+	resp, err := r.API.GetDataPeriod(ctx, r.id)
+	if err != nil {
+		return solaredge.DataPeriod{}, fmt.Errorf("data period: %w", err)
+	}
+	return resp.DataPeriod, nil
+}
+
+func (r *run) syncInverters(ctx context.Context, start, end time.Time) error {
+	if err := r.request(); err != nil {
+		return err
+	}
+	components, err := r.API.GetComponents(ctx, r.id)
+	if err != nil {
+		return fmt.Errorf("components: %w", err)
+	}
+	for _, inverter := range components.Reporters.List {
+		if err = r.syncSeries(ctx, store.Key{SiteID: r.id, Type: string(SeriesInverter), Name: inverter.SerialNumber}, start, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *run) syncSeries(ctx context.Context, key store.Key, start, end time.Time) error {
+	if checkpoint, ok := r.checkpoints.Get(key); ok && checkpoint.After(start) {
+		start = checkpoint
+	}
+	// the last day may be incomplete: don't move the checkpoint beyond its start, so it is downloaded again next time
+	lastDay := end.AddDate(0, 0, -1)
+
+	for windowStart := start; windowStart.Before(end); {
+		windowEnd := Series(key.Type).window(windowStart)
+		if windowEnd.After(end) {
+			windowEnd = end
+		}
+		if err := r.request(); err != nil {
+			return err
+		}
+		batch, err := r.fetch(ctx, key, windowStart, windowEnd)
+		if err != nil {
+			return fmt.Errorf("%s [%s - %s]: %w", key, windowStart.Format(time.DateOnly), windowEnd.Format(time.DateOnly), err)
+		}
+		if err = r.Sink.Write(ctx, batch); err != nil {
+			return fmt.Errorf("%s: write: %w", key, err)
+		}
+		checkpoint := windowEnd
+		if checkpoint.After(lastDay) {
+			checkpoint = lastDay
+		}
+		if checkpoint.After(start) {
+			if err = r.checkpoints.Set(key, checkpoint); err != nil {
+				return fmt.Errorf("%s: checkpoint: %w", key, err)
+			}
+		}
+		windowStart = windowEnd
+	}
+	return nil
+}
+
+func (r *run) fetch(ctx context.Context, key store.Key, start, end time.Time) (Batch, error) {
+	batch := Batch{Key: key, Start: start, End: end}
+	// the API's end time is inclusive
+	last := end.Add(-time.Second)
+	switch Series(key.Type) {
+	case SeriesPower:
+		resp, err := r.API.GetPowerMeasurements(ctx, r.id, start, last)
+		batch.Power = resp.Power.Values
+		return batch, err
+	case SeriesEnergyDetails:
+		resp, err := r.API.GetEnergyDetails(ctx, r.id, solaredge.TimeUnitQuarter, start, last)
+		batch.Meters = resp.EnergyDetails.Meters
+		return batch, err
+	case SeriesStorage:
+		resp, err := r.API.GetStorageData(ctx, r.id, start, last)
+		batch.Batteries = resp.StorageData.Batteries
+		return batch, err
+	case SeriesInverter:
+		resp, err := r.API.GetInverterTechnicalData(ctx, r.id, key.Name, start, last)
+		batch.Inverter = resp.Data.Telemetries
+		return batch, err
+	default:
+		return batch, fmt.Errorf("unsupported series %q", key.Type)
+	}
+}
@@ -0,0 +1,197 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/store"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeAPI struct {
+	dataPeriod solaredge.DataPeriod
+	err        error
+	calls      []string
+	lock       sync.Mutex
+}
+
+func (f *fakeAPI) record(call string, start, end time.Time) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.calls = append(f.calls, call+" "+start.Format(time.DateTime)+" "+end.Format(time.DateTime))
+}
+
+func (f *fakeAPI) GetDataPeriod(_ context.Context, _ int) (solaredge.GetDataPeriodResponse, error) {
+	return solaredge.GetDataPeriodResponse{DataPeriod: f.dataPeriod}, nil
+}
+
+func (f *fakeAPI) GetComponents(_ context.Context, _ int) (solaredge.GetComponentsResponse, error) {
+	var resp solaredge.GetComponentsResponse
+	resp.Reporters.Count = 1
+	resp.Reporters.List = []solaredge.Inverter{{SerialNumber: "SN1"}}
+	return resp, nil
+}
+
+func (f *fakeAPI) GetPowerMeasurements(_ context.Context, _ int, start, end time.Time) (solaredge.GetPowerMeasurementsResponse, error) {
+	f.record("power", start, end)
+	return solaredge.GetPowerMeasurementsResponse{Power: solaredge.PowerMeasurements{Values: []solaredge.Value{{Date: solaredge.Time(start), Value: 1}}}}, f.err
+}
+
+func (f *fakeAPI) GetEnergyDetails(_ context.Context, _ int, _ solaredge.TimeUnit, start, end time.Time) (solaredge.GetEnergyDetailsResponse, error) {
+	f.record("energy", start, end)
+	return solaredge.GetEnergyDetailsResponse{}, f.err
+}
+
+func (f *fakeAPI) GetStorageData(_ context.Context, _ int, start, end time.Time) (solaredge.GetStorageDataResponse, error) {
+	f.record("storage", start, end)
+	return solaredge.GetStorageDataResponse{}, f.err
+}
+
+func (f *fakeAPI) GetInverterTechnicalData(_ context.Context, _ int, serialNr string, start, end time.Time) (solaredge.GetInverterTechnicalDataResponse, error) {
+	f.record("inverter/"+serialNr, start, end)
+	return solaredge.GetInverterTechnicalDataResponse{}, f.err
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestSyncer_Sync(t *testing.T) {
+	api := fakeAPI{dataPeriod: solaredge.DataPeriod{
+		StartDate: solaredge.Date(date(2024, time.January, 15)),
+		EndDate:   solaredge.Date(date(2024, time.March, 1)),
+	}}
+	checkpoints, err := OpenCheckpoints(filepath.Join(t.TempDir(), "checkpoints.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var batches []Batch
+	s := Syncer{
+		API:         &api,
+		Checkpoints: checkpoints,
+		Series:      []Series{SeriesPower, SeriesInverter},
+		Sink: SinkFunc(func(_ context.Context, batch Batch) error {
+			batches = append(batches, batch)
+			return nil
+		}),
+	}
+
+	ctx := context.Background()
+	if err = s.Sync(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"power 2024-01-15 00:00:00 2024-02-14 23:59:59",
+		"power 2024-02-15 00:00:00 2024-03-01 23:59:59",
+		"inverter/SN1 2024-01-15 00:00:00 2024-01-21 23:59:59",
+		"inverter/SN1 2024-01-22 00:00:00 2024-01-28 23:59:59",
+		"inverter/SN1 2024-01-29 00:00:00 2024-02-04 23:59:59",
+		"inverter/SN1 2024-02-05 00:00:00 2024-02-11 23:59:59",
+		"inverter/SN1 2024-02-12 00:00:00 2024-02-18 23:59:59",
+		"inverter/SN1 2024-02-19 00:00:00 2024-02-25 23:59:59",
+		"inverter/SN1 2024-02-26 00:00:00 2024-03-01 23:59:59",
+	}
+	assertCalls(t, api.calls, want)
+	if len(batches) != len(want) || len(batches[0].Power) != 1 || batches[2].Key.Name != "SN1" {
+		t.Errorf("unexpected batches: %+v", batches)
+	}
+
+	// the last day is downloaded again, as it may have been incomplete.
+	if got, ok := checkpoints.Get(store.Key{SiteID: 1, Type: string(SeriesPower)}); !ok || !got.Equal(date(2024, time.March, 1)) {
+		t.Errorf("unexpected checkpoint: %v (%v)", got, ok)
+	}
+
+	// next sync only downloads new data
+	api.calls = nil
+	api.dataPeriod.EndDate = solaredge.Date(date(2024, time.March, 3))
+	if err = s.Sync(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	assertCalls(t, api.calls, []string{
+		"power 2024-03-01 00:00:00 2024-03-03 23:59:59",
+		"inverter/SN1 2024-03-01 00:00:00 2024-03-03 23:59:59",
+	})
+}
+
+func TestSyncer_Resume(t *testing.T) {
+	api := fakeAPI{dataPeriod: solaredge.DataPeriod{
+		StartDate: solaredge.Date(date(2024, time.January, 1)),
+		EndDate:   solaredge.Date(date(2024, time.March, 31)),
+	}}
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+	checkpoints, err := OpenCheckpoints(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Syncer{
+		API:         &api,
+		Checkpoints: checkpoints,
+		Series:      []Series{SeriesEnergyDetails},
+		Sink:        SinkFunc(func(context.Context, Batch) error { return nil }),
+		MaxRequests: 2,
+	}
+
+	ctx := context.Background()
+	if err = s.Sync(ctx, 1); !errors.Is(err, ErrBudgetExhausted) {
+		t.Fatalf("got error %v, want %v", err, ErrBudgetExhausted)
+	}
+	assertCalls(t, api.calls, []string{"energy 2024-01-01 00:00:00 2024-01-31 23:59:59"})
+
+	// a failing request stops the sync, without losing the progress made so far
+	api.calls = nil
+	api.err = errors.New("quota exceeded")
+	if checkpoints, err = OpenCheckpoints(path); err != nil {
+		t.Fatal(err)
+	}
+	s.Checkpoints = checkpoints
+	s.MaxRequests = 0
+	if err = s.Sync(ctx, 1); err == nil {
+		t.Fatal("expected an error")
+	}
+	assertCalls(t, api.calls, []string{"energy 2024-02-01 00:00:00 2024-02-29 23:59:59"})
+
+	api.calls = nil
+	api.err = nil
+	if err = s.Sync(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	assertCalls(t, api.calls, []string{
+		"energy 2024-02-01 00:00:00 2024-02-29 23:59:59",
+		"energy 2024-03-01 00:00:00 2024-03-31 23:59:59",
+	})
+}
+
+func TestSyncer_NoCheckpoints(t *testing.T) {
+	api := fakeAPI{dataPeriod: solaredge.DataPeriod{
+		StartDate: solaredge.Date(date(2024, time.March, 1)),
+		EndDate:   solaredge.Date(date(2024, time.March, 2)),
+	}}
+	s := Syncer{
+		API:    &api,
+		Series: []Series{SeriesPower},
+		Sink:   SinkFunc(func(context.Context, Batch) error { return nil }),
+	}
+	// without checkpoints, each sync downloads the full history
+	for range 2 {
+		api.calls = nil
+		if err := s.Sync(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+		assertCalls(t, api.calls, []string{"power 2024-03-01 00:00:00 2024-03-02 23:59:59"})
+	}
+}
+
+func assertCalls(t *testing.T, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d calls, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("call %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
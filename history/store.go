@@ -0,0 +1,91 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/store"
+	"time"
+)
+
+// StoreSink returns a Sink that writes the downloaded data to a store.Store. loc is the site's time zone: each point
+// holds the actual moment of the measurement, as with the export package (see solaredge.Time.Local). If loc is nil,
+// timestamps are interpreted as UTC.
+//
+//   - SeriesPower is written to a series of type store.TypePower, with a field "power"
+//   - SeriesEnergyDetails is written to a series of type store.TypeMeter per meter type, with a field "energy"
+//   - SeriesStorage is written to a series of type store.TypeBattery per battery
+//   - SeriesInverter is written to a series of type store.TypeInverter per inverter
+func StoreSink(s store.Store, loc *time.Location) Sink {
+	return SinkFunc(func(ctx context.Context, batch Batch) error {
+		siteID := batch.Key.SiteID
+		switch Series(batch.Key.Type) {
+		case SeriesPower:
+			return s.Write(ctx, store.Key{SiteID: siteID, Type: store.TypePower}, valuePoints(batch.Power, "power", loc))
+		case SeriesEnergyDetails:
+			for _, meter := range batch.Meters {
+				if err := s.Write(ctx, store.Key{SiteID: siteID, Type: store.TypeMeter, Name: meter.Type}, valuePoints(meter.Values, "energy", loc)); err != nil {
+					return err
+				}
+			}
+		case SeriesStorage:
+			for _, battery := range batch.Batteries {
+				if err := s.Write(ctx, store.Key{SiteID: siteID, Type: store.TypeBattery, Name: battery.SerialNumber}, batteryPoints(battery.Telemetries, loc)); err != nil {
+					return err
+				}
+			}
+		case SeriesInverter:
+			return s.Write(ctx, store.Key{SiteID: siteID, Type: store.TypeInverter, Name: batch.Key.Name}, inverterPoints(batch.Inverter, loc))
+		default:
+			return fmt.Errorf("unsupported series %q", batch.Key.Type)
+		}
+		return nil
+	})
+}
+
+func valuePoints(values []solaredge.Value, field string, loc *time.Location) []store.Point {
+	points := make([]store.Point, 0, len(values))
+	for _, v := range values {
+		points = append(points, store.Point{Time: v.Date.Local(loc), Fields: map[string]float64{field: v.Value}})
+	}
+	return points
+}
+
+func batteryPoints(telemetries []solaredge.BatteryTelemetry, loc *time.Location) []store.Point {
+	points := make([]store.Point, 0, len(telemetries))
+	for _, t := range telemetries {
+		points = append(points, store.Point{Time: t.TimeStamp.Local(loc), Fields: map[string]float64{
+			"power":                    t.Power,
+			"batteryState":             float64(t.BatteryState),
+			"lifeTimeEnergyCharged":    t.LifeTimeEnergyCharged,
+			"lifeTimeEnergyDischarged": t.LifeTimeEnergyDischarged,
+			"fullPackEnergyAvailable":  t.FullPackEnergyAvailable,
+			"internalTemp":             t.InternalTemp,
+			"ACGridCharging":           t.ACGridCharging,
+		}})
+	}
+	return points
+}
+
+func inverterPoints(telemetries []solaredge.InverterTelemetry, loc *time.Location) []store.Point {
+	points := make([]store.Point, 0, len(telemetries))
+	for _, t := range telemetries {
+		points = append(points, store.Point{Time: t.Time.Local(loc), Fields: map[string]float64{
+			"dcVoltage":             t.DcVoltage,
+			"groundFaultResistance": t.GroundFaultResistance,
+			"operationMode":         float64(t.OperationMode),
+			"powerLimit":            t.PowerLimit,
+			"temperature":           t.Temperature,
+			"totalActivePower":      t.TotalActivePower,
+			"totalEnergy":           t.TotalEnergy,
+			"acCurrent":             t.L1Data.AcCurrent,
+			"acFrequency":           t.L1Data.AcFrequency,
+			"acVoltage":             t.L1Data.AcVoltage,
+			"activePower":           t.L1Data.ActivePower,
+			"apparentPower":         t.L1Data.ApparentPower,
+			"cosPhi":                t.L1Data.CosPhi,
+			"reactivePower":         t.L1Data.ReactivePower,
+		}})
+	}
+	return points
+}
@@ -0,0 +1,75 @@
+package history
+
+import (
+	"context"
+	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/store"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStoreSink(t *testing.T) {
+	ts := solaredge.Time(date(2024, time.June, 1))
+	batches := []Batch{
+		{Key: store.Key{SiteID: 1, Type: string(SeriesPower)}, Power: []solaredge.Value{{Date: ts, Value: 100}}},
+		{Key: store.Key{SiteID: 1, Type: string(SeriesEnergyDetails)}, Meters: []solaredge.MeterReadings{
+			{Type: "Production", Values: []solaredge.Value{{Date: ts, Value: 10}}},
+			{Type: "FeedIn", Values: []solaredge.Value{{Date: ts, Value: 5}}},
+		}},
+		{Key: store.Key{SiteID: 1, Type: string(SeriesStorage)}, Batteries: []solaredge.Battery{
+			{SerialNumber: "BAT1", Telemetries: []solaredge.BatteryTelemetry{{TimeStamp: ts, Power: 500}}},
+		}},
+		{Key: store.Key{SiteID: 1, Type: string(SeriesInverter), Name: "SN1"}, Inverter: []solaredge.InverterTelemetry{{Time: ts, TotalActivePower: 1000}}},
+	}
+
+	loc, err := time.LoadLocation("Europe/Brussels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	var s store.Memory
+	sink := StoreSink(&s, loc)
+	for _, batch := range batches {
+		if err := sink.Write(ctx, batch); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Write(ctx, Batch{Key: store.Key{Type: "invalid"}}); err == nil {
+		t.Error("expected an error")
+	}
+
+	keys, err := s.Keys(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []store.Key{
+		{SiteID: 1, Type: store.TypeBattery, Name: "BAT1"},
+		{SiteID: 1, Type: store.TypeInverter, Name: "SN1"},
+		{SiteID: 1, Type: store.TypeMeter, Name: "FeedIn"},
+		{SiteID: 1, Type: store.TypeMeter, Name: "Production"},
+		{SiteID: 1, Type: store.TypePower},
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Fatalf("got keys %v, want %v", keys, want)
+	}
+
+	fields := map[store.Key][2]any{
+		want[0]: {"power", 500.0},
+		want[1]: {"totalActivePower", 1000.0},
+		want[2]: {"energy", 5.0},
+		want[3]: {"energy", 10.0},
+		want[4]: {"power", 100.0},
+	}
+	// points hold the actual moment of the measurement: midnight in Brussels
+	local := time.Date(2024, time.June, 1, 0, 0, 0, 0, loc)
+	for key, field := range fields {
+		points, err := s.Read(ctx, key, local, local.Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(points) != 1 || !points[0].Time.Equal(local) || points[0].Fields[field[0].(string)] != field[1].(float64) {
+			t.Errorf("%s: unexpected points: %v", key, points)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// Package atomicfile replaces files without leaving a partially written file behind.
+package atomicfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile calls write to write the content of the file to a temporary file in the same directory, and replaces the
+// file at path with the temporary file once write succeeds. If write fails or the write is interrupted, the file at
+// path is left unchanged.
+func WriteFile(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if err = write(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
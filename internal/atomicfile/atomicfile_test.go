@@ -0,0 +1,44 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.json")
+	write := func(content string) func(io.Writer) error {
+		return func(w io.Writer) error {
+			_, err := io.WriteString(w, content)
+			return err
+		}
+	}
+
+	if err := WriteFile(path, write("first")); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(path, write("second")); err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed write leaves the file unchanged
+	errWrite := errors.New("write failed")
+	if err := WriteFile(path, func(w io.Writer) error {
+		_, _ = io.WriteString(w, "partial")
+		return errWrite
+	}); !errors.Is(err, errWrite) {
+		t.Errorf("expected errWrite, got %v", err)
+	}
+
+	if body, err := os.ReadFile(path); err != nil || string(body) != "second" {
+		t.Errorf("got %q, %v", body, err)
+	}
+	// no temporary files are left behind
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("got %d files, want 1", len(entries))
+	}
+}
@@ -0,0 +1,20 @@
+// Package sitepath extracts the site of a SolarEdge API call from its path.
+package sitepath
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SiteID returns the site ID of a call's path (e.g. "/site/1/overview" or "/equipment/1/list"), or zero if the call
+// isn't for a single site.
+func SiteID(path string) int {
+	for _, prefix := range []string{"/site/", "/equipment/"} {
+		if rest, ok := strings.CutPrefix(path, prefix); ok {
+			id, _, _ := strings.Cut(rest, "/")
+			siteID, _ := strconv.Atoi(id)
+			return siteID
+		}
+	}
+	return 0
+}
@@ -0,0 +1,20 @@
+package sitepath
+
+import "testing"
+
+func TestSiteID(t *testing.T) {
+	tests := []struct {
+		path string
+		want int
+	}{
+		{path: "/sites/list", want: 0},
+		{path: "/version/current", want: 0},
+		{path: "/site/123/details", want: 123},
+		{path: "/equipment/123/SN1/data", want: 123},
+	}
+	for _, tt := range tests {
+		if got := SiteID(tt.path); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.path, got, tt.want)
+		}
+	}
+}
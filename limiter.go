@@ -0,0 +1,110 @@
+package solaredge
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// PriorityLimiter is a Limiter that lets one call through per interval. When several calls are waiting, the call with
+// the highest priority (see CallPriority) goes first. Calls with the same priority go in order of arrival.
+//
+// Use a PriorityLimiter to let interactive requests jump ahead of background work, like downloading a site's history,
+// when both share a Client.
+type PriorityLimiter struct {
+	timer    *time.Timer
+	next     time.Time
+	waiters  waiters
+	interval time.Duration
+	seq      uint64
+	lock     sync.Mutex
+}
+
+// NewPriorityLimiter returns a PriorityLimiter that lets one call through per interval.
+func NewPriorityLimiter(interval time.Duration) *PriorityLimiter {
+	return &PriorityLimiter{interval: interval}
+}
+
+var _ Limiter = &PriorityLimiter{}
+
+// Wait blocks until the call may be sent, or returns an error if the context is done.
+func (l *PriorityLimiter) Wait(ctx context.Context) error {
+	l.lock.Lock()
+	w := &waiter{priority: PriorityFromContext(ctx), seq: l.seq, ready: make(chan struct{})}
+	l.seq++
+	heap.Push(&l.waiters, w)
+	l.release()
+	l.lock.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		// if the waiter was released in the meantime, its turn is lost
+		if w.index >= 0 {
+			heap.Remove(&l.waiters, w.index)
+		}
+		return ctx.Err()
+	}
+}
+
+// release lets the waiters through whose turn it is, and sets a timer for the next one. The caller must hold the lock.
+func (l *PriorityLimiter) release() {
+	for l.timer == nil && len(l.waiters) > 0 {
+		now := time.Now()
+		if now.Before(l.next) {
+			l.timer = time.AfterFunc(l.next.Sub(now), func() {
+				l.lock.Lock()
+				defer l.lock.Unlock()
+				l.timer = nil
+				l.release()
+			})
+			return
+		}
+		close(heap.Pop(&l.waiters).(*waiter).ready)
+		l.next = now.Add(l.interval)
+	}
+}
+
+type waiter struct {
+	ready    chan struct{}
+	seq      uint64
+	index    int
+	priority Priority
+}
+
+// waiters is a heap of waiters, ordered by priority and then by order of arrival.
+type waiters []*waiter
+
+func (w waiters) Len() int { return len(w) }
+
+func (w waiters) Less(i, j int) bool {
+	if w[i].priority != w[j].priority {
+		return w[i].priority > w[j].priority
+	}
+	return w[i].seq < w[j].seq
+}
+
+func (w waiters) Swap(i, j int) {
+	w[i], w[j] = w[j], w[i]
+	w[i].index = i
+	w[j].index = j
+}
+
+func (w *waiters) Push(x any) {
+	item := x.(*waiter)
+	item.index = len(*w)
+	*w = append(*w, item)
+}
+
+func (w *waiters) Pop() any {
+	old := *w
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	item.index = -1
+	*w = old[:len(old)-1]
+	return item
+}
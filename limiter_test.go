@@ -0,0 +1,73 @@
+package solaredge
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPriorityLimiter(t *testing.T) {
+	l := NewPriorityLimiter(20 * time.Millisecond)
+	ctx := context.Background()
+
+	// hold all calls until they are queued
+	l.lock.Lock()
+	l.next = time.Now().Add(time.Hour)
+	l.lock.Unlock()
+
+	var lock sync.Mutex
+	var order []Priority
+	var wg sync.WaitGroup
+	for i, p := range []Priority{PriorityBackground, PriorityNormal, PriorityInteractive} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.Wait(WithCallOptions(ctx, CallPriority(p))); err != nil {
+				t.Error(err)
+				return
+			}
+			lock.Lock()
+			order = append(order, p)
+			lock.Unlock()
+		}()
+		for queued := false; !queued; time.Sleep(time.Millisecond) {
+			l.lock.Lock()
+			queued = len(l.waiters) == i+1
+			l.lock.Unlock()
+		}
+	}
+
+	l.lock.Lock()
+	l.timer.Stop()
+	l.timer = nil
+	l.next = time.Time{}
+	l.release()
+	l.lock.Unlock()
+	wg.Wait()
+
+	want := []Priority{PriorityInteractive, PriorityNormal, PriorityBackground}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("got order %v, want %v", order, want)
+		}
+	}
+}
+
+func TestPriorityLimiter_Cancel(t *testing.T) {
+	l := NewPriorityLimiter(time.Hour)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if len(l.waiters) != 0 {
+		t.Errorf("got %d waiters, want 0", len(l.waiters))
+	}
+}
@@ -0,0 +1,373 @@
+/*
+Package mqtt publishes the current state of SolarEdge sites to an MQTT broker, along with Home Assistant discovery
+configurations, so sites appear automatically in Home Assistant (including its Energy dashboard).
+
+The package doesn't include an MQTT client: users plug in their client of choice by implementing Publisher.
+
+Each site is published as a Home Assistant device, with a device per inverter connected to it. The state of a site
+and of its inverters is published to one topic per entity:
+
+	<prefix>/<site id>/<entity>
+	<prefix>/<site id>/<serial number>/<entity>
+
+where entity is one of the Entity constants. Discovery configurations are published, retained, to
+
+	<discovery prefix>/<component>/solaredge_<site id>/<entity>/config
+	<discovery prefix>/<component>/solaredge_<site id>_<serial number>/<entity>/config
+
+where component is "sensor", or "binary_sensor" for EntityBatteryCritical.
+*/
+package mqtt
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/clambin/solaredge/v2"
+	"strconv"
+	"strings"
+)
+
+// Default topic prefixes.
+const (
+	DefaultPrefix          = "solaredge"
+	DefaultDiscoveryPrefix = "homeassistant"
+)
+
+// A Publisher publishes a message to an MQTT broker.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, retain bool, payload []byte) error
+}
+
+// PublisherFunc is an adapter to allow the use of an ordinary function as a Publisher.
+type PublisherFunc func(ctx context.Context, topic string, retain bool, payload []byte) error
+
+// Publish calls f(ctx, topic, retain, payload).
+func (f PublisherFunc) Publish(ctx context.Context, topic string, retain bool, payload []byte) error {
+	return f(ctx, topic, retain, payload)
+}
+
+// Entity is the name of a published value.
+type Entity string
+
+// Entities published for each site.
+const (
+	// EntityPVPower is the current power produced by the panels, as reported by PowerFlow (W).
+	EntityPVPower Entity = "pv_power"
+	// EntityLoadPower is the current power consumed by the site, as reported by PowerFlow (W).
+	EntityLoadPower Entity = "load_power"
+	// EntityGridPower is the current power drawn from the grid, as reported by PowerFlow (W).
+	// The value is negative when power is fed into the grid.
+	EntityGridPower Entity = "grid_power"
+	// EntityBatteryPower is the current power delivered by the battery, as reported by PowerFlow (W).
+	// The value is negative when the battery is charging.
+	EntityBatteryPower Entity = "battery_power"
+	// EntityBatteryLevel is the battery's charge level, as reported by PowerFlow (%).
+	EntityBatteryLevel Entity = "battery_level"
+	// EntityBatteryCritical is ON when the battery is in a critical state, as reported by PowerFlow.
+	EntityBatteryCritical Entity = "battery_critical"
+	// EntityCurrentPower is the current power produced by the site, as reported by PowerOverview (W).
+	EntityCurrentPower Entity = "current_power"
+	// EntityEnergyToday is the energy produced today, as reported by PowerOverview (Wh).
+	EntityEnergyToday Entity = "energy_today"
+	// EntityEnergyLifetime is the energy produced since installation, as reported by PowerOverview (Wh).
+	// This is the entity to use as solar production in Home Assistant's Energy dashboard.
+	EntityEnergyLifetime Entity = "energy_lifetime"
+)
+
+// Entities published for each inverter.
+const (
+	// EntityInverterPower is the inverter's active power, as reported by InverterTelemetry (W).
+	EntityInverterPower Entity = "inverter_power"
+	// EntityInverterTemperature is the inverter's temperature, as reported by InverterTelemetry (°C).
+	EntityInverterTemperature Entity = "inverter_temperature"
+	// EntityInverterEnergy is the energy produced by the inverter since installation, as reported by InverterTelemetry (Wh).
+	EntityInverterEnergy Entity = "inverter_energy"
+)
+
+type entityConfig struct {
+	entity      Entity
+	name        string
+	deviceClass string
+	unit        string
+	stateClass  string
+	battery     bool
+	// binary entities are published as a binary_sensor, with state ON or OFF.
+	binary bool
+}
+
+func (e entityConfig) component() string {
+	if e.binary {
+		return "binary_sensor"
+	}
+	return "sensor"
+}
+
+var entities = []entityConfig{
+	{entity: EntityPVPower, name: "PV power", deviceClass: "power", unit: "W", stateClass: "measurement"},
+	{entity: EntityLoadPower, name: "Load power", deviceClass: "power", unit: "W", stateClass: "measurement"},
+	{entity: EntityGridPower, name: "Grid power", deviceClass: "power", unit: "W", stateClass: "measurement"},
+	{entity: EntityBatteryPower, name: "Battery power", deviceClass: "power", unit: "W", stateClass: "measurement", battery: true},
+	{entity: EntityBatteryLevel, name: "Battery level", deviceClass: "battery", unit: "%", stateClass: "measurement", battery: true},
+	{entity: EntityBatteryCritical, name: "Battery critical", deviceClass: "problem", battery: true, binary: true},
+	{entity: EntityCurrentPower, name: "Current power", deviceClass: "power", unit: "W", stateClass: "measurement"},
+	{entity: EntityEnergyToday, name: "Energy today", deviceClass: "energy", unit: "Wh", stateClass: "total_increasing"},
+	{entity: EntityEnergyLifetime, name: "Lifetime energy", deviceClass: "energy", unit: "Wh", stateClass: "total_increasing"},
+}
+
+var inverterEntities = []entityConfig{
+	{entity: EntityInverterPower, name: "Power", deviceClass: "power", unit: "W", stateClass: "measurement"},
+	{entity: EntityInverterTemperature, name: "Temperature", deviceClass: "temperature", unit: "°C", stateClass: "measurement"},
+	{entity: EntityInverterEnergy, name: "Lifetime energy", deviceClass: "energy", unit: "Wh", stateClass: "total_increasing"},
+}
+
+// Bridge publishes SolarEdge data to MQTT.
+type Bridge struct {
+	Publisher Publisher
+	// Prefix of the state topics. Defaults to DefaultPrefix.
+	Prefix string
+	// Prefix of the discovery topics. Defaults to DefaultDiscoveryPrefix.
+	DiscoveryPrefix string
+}
+
+// Discover publishes the Home Assistant discovery configurations of a site and its inverters. The site's device is
+// described using the site's details, and each inverter in the inventory becomes a device connected to it.
+// Battery entities are only published if the inventory contains batteries.
+//
+// Discovery configurations are retained, so Discover only needs to be called when the site's configuration changes.
+func (b Bridge) Discover(ctx context.Context, details solaredge.SiteDetails, inventory solaredge.Inventory) error {
+	site := device{
+		Identifiers:  []string{deviceID(details.Id)},
+		Name:         cmp.Or(details.Name, "SolarEdge "+strconv.Itoa(details.Id)),
+		Manufacturer: "SolarEdge",
+	}
+	for _, e := range entities {
+		if e.battery && len(inventory.Batteries) == 0 {
+			continue
+		}
+		if err := b.discover(ctx, deviceID(details.Id), b.StateTopic(details.Id, e.entity), e, site); err != nil {
+			return err
+		}
+	}
+	for _, inverter := range inventory.Inverters {
+		id := inverterDeviceID(details.Id, inverter.SN)
+		d := device{
+			Identifiers:  []string{id},
+			Name:         cmp.Or(inverter.Name, "Inverter "+inverter.SN),
+			Manufacturer: cmp.Or(inverter.Manufacturer, "SolarEdge"),
+			Model:        inverter.Model,
+			SerialNumber: inverter.SN,
+			SWVersion:    inverter.CPUVersion,
+			ViaDevice:    deviceID(details.Id),
+		}
+		for _, e := range inverterEntities {
+			if err := b.discover(ctx, id, b.InverterStateTopic(details.Id, inverter.SN, e.entity), e, d); err != nil {
+				return fmt.Errorf("%s: %w", inverter.SN, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (b Bridge) discover(ctx context.Context, id string, stateTopic string, e entityConfig, d device) error {
+	cfg := discoveryConfig{
+		Name:              e.name,
+		UniqueID:          id + "_" + string(e.entity),
+		ObjectID:          id + "_" + string(e.entity),
+		StateTopic:        stateTopic,
+		DeviceClass:       e.deviceClass,
+		UnitOfMeasurement: e.unit,
+		StateClass:        e.stateClass,
+		Device:            d,
+	}
+	payload, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err = b.Publisher.Publish(ctx, b.discoveryTopic(e.component(), id, e.entity), true, payload); err != nil {
+		return fmt.Errorf("%s: %w", e.entity, err)
+	}
+	return nil
+}
+
+// PublishPowerFlow publishes the current power flow of a site. Battery entities are only published if the site
+// reports a battery. Power is converted from the flow's unit to W. If the flow has no unit, kW is assumed.
+func (b Bridge) PublishPowerFlow(ctx context.Context, siteID int, flow solaredge.PowerFlow) error {
+	// the API reports the power flow in kW
+	unit := cmp.Or(flow.Unit, solaredge.UnitKW)
+	toW := func(value float64) (float64, error) {
+		q, err := solaredge.Quantity{Unit: unit, Value: value}.Convert(solaredge.UnitW)
+		return q.Value, err
+	}
+
+	values := map[Entity]float64{
+		EntityPVPower:   flow.PV.CurrentPower,
+		EntityLoadPower: flow.Load.CurrentPower,
+		EntityGridPower: flow.Grid.CurrentPower,
+	}
+	// the API reports absolute values: the direction is found in the connections
+	if flowsTo(flow, "grid") {
+		values[EntityGridPower] = -values[EntityGridPower]
+	}
+	hasBattery := flow.Storage.Status != ""
+	if hasBattery {
+		values[EntityBatteryPower] = flow.Storage.CurrentPower
+		if flowsTo(flow, "storage") {
+			values[EntityBatteryPower] = -values[EntityBatteryPower]
+		}
+	}
+	for entity, value := range values {
+		var err error
+		if values[entity], err = toW(value); err != nil {
+			return fmt.Errorf("power flow: %w", err)
+		}
+	}
+	if hasBattery {
+		values[EntityBatteryLevel] = flow.Storage.ChargeLevel
+		values[EntityBatteryCritical] = 0
+		if flow.Storage.Critical {
+			values[EntityBatteryCritical] = 1
+		}
+	}
+	return b.publish(ctx, entities, values, func(e Entity) string { return b.StateTopic(siteID, e) })
+}
+
+func flowsTo(flow solaredge.PowerFlow, element string) bool {
+	for _, c := range flow.Connections {
+		if strings.EqualFold(c.To, element) {
+			return true
+		}
+	}
+	return false
+}
+
+// PublishPowerOverview publishes a site's current power and produced energy.
+func (b Bridge) PublishPowerOverview(ctx context.Context, siteID int, overview solaredge.PowerOverview) error {
+	return b.publish(ctx, entities, map[Entity]float64{
+		EntityCurrentPower:   overview.CurrentPower.Power,
+		EntityEnergyToday:    overview.LastDayData.Energy,
+		EntityEnergyLifetime: overview.LifeTimeData.Energy,
+	}, func(e Entity) string { return b.StateTopic(siteID, e) })
+}
+
+// PublishInverterTelemetry publishes an inverter's power, temperature and produced energy, as reported by
+// solaredge.Client.GetInverterTechnicalData. Use the most recent telemetry.
+func (b Bridge) PublishInverterTelemetry(ctx context.Context, siteID int, serialNr string, telemetry solaredge.InverterTelemetry) error {
+	return b.publish(ctx, inverterEntities, map[Entity]float64{
+		EntityInverterPower:       telemetry.TotalActivePower,
+		EntityInverterTemperature: telemetry.Temperature,
+		EntityInverterEnergy:      telemetry.TotalEnergy,
+	}, func(e Entity) string { return b.InverterStateTopic(siteID, serialNr, e) })
+}
+
+func (b Bridge) publish(ctx context.Context, configs []entityConfig, values map[Entity]float64, topic func(Entity) string) error {
+	var errs []error
+	for _, e := range configs {
+		value, ok := values[e.entity]
+		if !ok {
+			continue
+		}
+		payload := strconv.FormatFloat(value, 'f', -1, 64)
+		if e.binary {
+			payload = "OFF"
+			if value != 0 {
+				payload = "ON"
+			}
+		}
+		if err := b.Publisher.Publish(ctx, topic(e.entity), false, []byte(payload)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.entity, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Handler returns a handler for solaredge.Watcher.Run that publishes the events it receives.
+// Errors, including those reported by ErrorEvents, are passed to onError, if not nil.
+func (b Bridge) Handler(ctx context.Context, onError func(error)) func(solaredge.Event) {
+	return func(event solaredge.Event) {
+		var err error
+		switch e := event.(type) {
+		case solaredge.PowerFlowEvent:
+			err = b.PublishPowerFlow(ctx, e.SiteID, e.PowerFlow)
+		case solaredge.OverviewEvent:
+			err = b.PublishPowerOverview(ctx, e.SiteID, e.Overview)
+		case solaredge.ErrorEvent:
+			err = e.Err
+		}
+		if err != nil && onError != nil {
+			onError(fmt.Errorf("site %d: %w", event.Site(), err))
+		}
+	}
+}
+
+// StateTopic returns the topic to which a site's entity is published.
+func (b Bridge) StateTopic(siteID int, entity Entity) string {
+	return cmp.Or(b.Prefix, DefaultPrefix) + "/" + strconv.Itoa(siteID) + "/" + string(entity)
+}
+
+// InverterStateTopic returns the topic to which an inverter's entity is published.
+func (b Bridge) InverterStateTopic(siteID int, serialNr string, entity Entity) string {
+	return cmp.Or(b.Prefix, DefaultPrefix) + "/" + strconv.Itoa(siteID) + "/" + topicSafe(serialNr) + "/" + string(entity)
+}
+
+// DiscoveryTopic returns the topic to which the discovery configuration of a site's entity is published.
+func (b Bridge) DiscoveryTopic(siteID int, entity Entity) string {
+	component := "sensor"
+	for _, e := range entities {
+		if e.entity == entity {
+			component = e.component()
+			break
+		}
+	}
+	return b.discoveryTopic(component, deviceID(siteID), entity)
+}
+
+// InverterDiscoveryTopic returns the topic to which the discovery configuration of an inverter's entity is published.
+func (b Bridge) InverterDiscoveryTopic(siteID int, serialNr string, entity Entity) string {
+	return b.discoveryTopic("sensor", inverterDeviceID(siteID, serialNr), entity)
+}
+
+func (b Bridge) discoveryTopic(component string, id string, entity Entity) string {
+	return cmp.Or(b.DiscoveryPrefix, DefaultDiscoveryPrefix) + "/" + component + "/" + id + "/" + string(entity) + "/config"
+}
+
+func deviceID(siteID int) string {
+	return "solaredge_" + strconv.Itoa(siteID)
+}
+
+func inverterDeviceID(siteID int, serialNr string) string {
+	return deviceID(siteID) + "_" + topicSafe(serialNr)
+}
+
+// topicSafe replaces the characters of a serial number that aren't allowed in topics and Home Assistant IDs.
+func topicSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+type discoveryConfig struct {
+	Name              string `json:"name"`
+	UniqueID          string `json:"unique_id"`
+	ObjectID          string `json:"object_id"`
+	StateTopic        string `json:"state_topic"`
+	DeviceClass       string `json:"device_class"`
+	UnitOfMeasurement string `json:"unit_of_measurement,omitempty"`
+	StateClass        string `json:"state_class,omitempty"`
+	Device            device `json:"device"`
+}
+
+type device struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model,omitempty"`
+	SerialNumber string   `json:"serial_number,omitempty"`
+	SWVersion    string   `json:"sw_version,omitempty"`
+	ViaDevice    string   `json:"via_device,omitempty"`
+}
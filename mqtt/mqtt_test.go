@@ -0,0 +1,225 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/clambin/solaredge/v2"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type message struct {
+	payload string
+	retain  bool
+}
+
+type fakePublisher struct {
+	messages map[string]message
+	err      error
+	lock     sync.Mutex
+}
+
+func (p *fakePublisher) Publish(_ context.Context, topic string, retain bool, payload []byte) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	if p.messages == nil {
+		p.messages = make(map[string]message)
+	}
+	p.messages[topic] = message{payload: string(payload), retain: retain}
+	return nil
+}
+
+func TestBridge_Discover(t *testing.T) {
+	var details solaredge.SiteDetails
+	details.Id = 1
+	details.Name = "home"
+	inventory := solaredge.Inventory{Inverters: []solaredge.InverterEquipment{{SN: "7E12-34", Model: "SE3500H", CPUVersion: "4.0.0"}}}
+
+	var p fakePublisher
+	b := Bridge{Publisher: &p}
+	if err := b.Discover(context.Background(), details, inventory); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.messages) != 9 {
+		t.Errorf("got %d configs, want 9", len(p.messages))
+	}
+	if _, ok := p.messages["homeassistant/sensor/solaredge_1/battery_level/config"]; ok {
+		t.Error("battery entity published for a site without batteries")
+	}
+
+	msg, ok := p.messages["homeassistant/sensor/solaredge_1/energy_lifetime/config"]
+	if !ok || !msg.retain {
+		t.Fatalf("missing or not retained config: %v", msg)
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal([]byte(msg.payload), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"name":                "Lifetime energy",
+		"unique_id":           "solaredge_1_energy_lifetime",
+		"object_id":           "solaredge_1_energy_lifetime",
+		"state_topic":         "solaredge/1/energy_lifetime",
+		"device_class":        "energy",
+		"unit_of_measurement": "Wh",
+		"state_class":         "total_increasing",
+		"device": map[string]any{
+			"identifiers":  []any{"solaredge_1"},
+			"name":         "home",
+			"manufacturer": "SolarEdge",
+		},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %v, want %v", cfg, want)
+	}
+
+	// each inverter is a device, connected to the site
+	msg, ok = p.messages["homeassistant/sensor/solaredge_1_7E12-34/inverter_power/config"]
+	if !ok {
+		t.Fatal("missing inverter config")
+	}
+	if err := json.Unmarshal([]byte(msg.payload), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]any{
+		"name":                "Power",
+		"unique_id":           "solaredge_1_7E12-34_inverter_power",
+		"object_id":           "solaredge_1_7E12-34_inverter_power",
+		"state_topic":         "solaredge/1/7E12-34/inverter_power",
+		"device_class":        "power",
+		"unit_of_measurement": "W",
+		"state_class":         "measurement",
+		"device": map[string]any{
+			"identifiers":   []any{"solaredge_1_7E12-34"},
+			"name":          "Inverter 7E12-34",
+			"manufacturer":  "SolarEdge",
+			"model":         "SE3500H",
+			"serial_number": "7E12-34",
+			"sw_version":    "4.0.0",
+			"via_device":    "solaredge_1",
+		},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %v, want %v", cfg, want)
+	}
+
+	inventory.Batteries = []solaredge.BatteryEquipment{{SN: "BAT1"}}
+	b.DiscoveryPrefix = "ha"
+	if err := b.Discover(context.Background(), details, inventory); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok = p.messages["ha/sensor/solaredge_1/battery_level/config"]; !ok {
+		t.Error("battery entity not published for a site with batteries")
+	}
+	if topic := b.DiscoveryTopic(1, EntityBatteryCritical); topic != "ha/binary_sensor/solaredge_1/battery_critical/config" {
+		t.Errorf("unexpected topic %q", topic)
+	}
+	if _, ok = p.messages["ha/binary_sensor/solaredge_1/battery_critical/config"]; !ok {
+		t.Error("battery critical entity not published for a site with batteries")
+	}
+}
+
+func TestBridge_PublishPowerFlow(t *testing.T) {
+	var flow solaredge.PowerFlow
+	flow.Unit = solaredge.UnitKW
+	flow.Connections = append(flow.Connections,
+		struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+		}{From: "LOAD", To: "Grid"},
+		struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+		}{From: "PV", To: "Storage"},
+	)
+	flow.PV.CurrentPower = 3
+	flow.Load.CurrentPower = 1
+	flow.Grid.CurrentPower = 1.5
+	flow.Storage.Status = "Charging"
+	flow.Storage.CurrentPower = 0.5
+	flow.Storage.ChargeLevel = 80
+
+	var p fakePublisher
+	b := Bridge{Publisher: &p, Prefix: "solar"}
+	if err := b.PublishPowerFlow(context.Background(), 1, flow); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]message{
+		"solar/1/pv_power":         {payload: "3000"},
+		"solar/1/load_power":       {payload: "1000"},
+		"solar/1/grid_power":       {payload: "-1500"},
+		"solar/1/battery_power":    {payload: "-500"},
+		"solar/1/battery_level":    {payload: "80"},
+		"solar/1/battery_critical": {payload: "OFF"},
+	}
+	if !reflect.DeepEqual(p.messages, want) {
+		t.Errorf("got %v, want %v", p.messages, want)
+	}
+
+	// a flow without a unit is reported in kW
+	flow.Unit = ""
+	flow.Storage.Critical = true
+	if err := b.PublishPowerFlow(context.Background(), 1, flow); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.messages["solar/1/pv_power"].payload; got != "3000" {
+		t.Errorf("got PV power %s, want 3000", got)
+	}
+	if got := p.messages["solar/1/battery_critical"].payload; got != "ON" {
+		t.Errorf("got battery critical %s, want ON", got)
+	}
+
+	flow.Unit = "invalid"
+	if err := b.PublishPowerFlow(context.Background(), 1, flow); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestBridge_PublishInverterTelemetry(t *testing.T) {
+	var p fakePublisher
+	b := Bridge{Publisher: &p}
+	telemetry := solaredge.InverterTelemetry{TotalActivePower: 2500, Temperature: 41.5, TotalEnergy: 1e6}
+	if err := b.PublishInverterTelemetry(context.Background(), 1, "7E12/34", telemetry); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]message{
+		"solaredge/1/7E12_34/inverter_power":       {payload: "2500"},
+		"solaredge/1/7E12_34/inverter_temperature": {payload: "41.5"},
+		"solaredge/1/7E12_34/inverter_energy":      {payload: "1000000"},
+	}
+	if !reflect.DeepEqual(p.messages, want) {
+		t.Errorf("got %v, want %v", p.messages, want)
+	}
+}
+
+func TestBridge_Handler(t *testing.T) {
+	var p fakePublisher
+	var errs []error
+	h := Bridge{Publisher: &p}.Handler(context.Background(), func(err error) { errs = append(errs, err) })
+
+	var overview solaredge.PowerOverview
+	overview.CurrentPower.Power = 1000
+	overview.LastDayData.Energy = 5000
+	overview.LifeTimeData.Energy = 1e6
+	h(solaredge.OverviewEvent{SiteID: 1, Overview: overview})
+	want := map[string]message{
+		"solaredge/1/current_power":   {payload: "1000"},
+		"solaredge/1/energy_today":    {payload: "5000"},
+		"solaredge/1/energy_lifetime": {payload: "1000000"},
+	}
+	if !reflect.DeepEqual(p.messages, want) {
+		t.Errorf("got %v, want %v", p.messages, want)
+	}
+
+	h(solaredge.ErrorEvent{SiteID: 1, Err: errors.New("fail")})
+	p.err = errors.New("not connected")
+	h(solaredge.OverviewEvent{SiteID: 1, Overview: overview})
+	if len(errs) != 2 || !errors.Is(errs[1], p.err) {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
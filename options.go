@@ -0,0 +1,132 @@
+package solaredge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrInvalidKey is returned by NewClient if the API key is not a valid SolarEdge API key.
+var ErrInvalidKey = errors.New("invalid api key")
+
+// SolarEdge API keys consist of 32 uppercase letters and digits.
+var keyFormat = regexp.MustCompile(`^[A-Z0-9]{32}$`)
+
+// A Limiter limits the rate at which the Client sends requests. Wait blocks until the next request may be sent,
+// or returns an error if the context is done. golang.org/x/time/rate.Limiter implements Limiter.
+//
+// A Limiter can use PriorityFromContext to order the calls waiting to be sent. See PriorityLimiter.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// An Option configures a Client created by NewClient.
+type Option func(*Client)
+
+// WithBaseURL sets the URL of the API server. Use this to point the Client to a stand-in or a proxy.
+// The default is https://monitoringapi.solaredge.com.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) { c.baseURL = strings.TrimSuffix(baseURL, "/") }
+}
+
+// WithHTTPClient sets the HTTP client used to send requests. The default is http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.HTTPClient = httpClient }
+}
+
+// WithUserAgent sets the User-Agent header of each request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// WithTimeout limits the duration of each call, including the time spent waiting for the Limiter and between retries.
+// Use CallTimeout to override this for a single call.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) { c.timeout = timeout }
+}
+
+// WithRetries retries a failed call up to the specified number of times, if the error may be transient: the request
+// couldn't be sent or the server failed (status 5xx). Status 429 isn't retried, as the SolarEdge API returns it
+// when the daily quota is exhausted. The wait between retries starts at one
+// second and doubles with each retry. The default is not to retry. Use CallRetries to override this for a single call.
+func WithRetries(retries int) Option {
+	return func(c *Client) { c.retries = retries }
+}
+
+// WithAPIVersion sets the version of the API requested from the server. The default is 1.0.0.
+func WithAPIVersion(version string) Option {
+	return func(c *Client) { c.apiVersion = version }
+}
+
+// WithLogger logs each call at debug level. The API key is never logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) { c.logger = logger }
+}
+
+// WithLimiter limits the rate at which the Client sends requests.
+func WithLimiter(limiter Limiter) Option {
+	return func(c *Client) { c.limiter = limiter }
+}
+
+// WithQuotaTracker counts the Client's calls in the QuotaTracker. See Client.Quota and SiteClient.Quota.
+func WithQuotaTracker(tracker *QuotaTracker) Option {
+	return func(c *Client) { c.quota = tracker }
+}
+
+// WithNormalizeUnits converts all values to their base unit when decoding a response: power is reported in W,
+// energy in Wh and mass in KG.
+func WithNormalizeUnits() Option {
+	return func(c *Client) { c.normalizeUnits = true }
+}
+
+// DefaultMaxResponseSize is the default maximum size of a response. See WithMaxResponseSize.
+const DefaultMaxResponseSize = 32 << 20
+
+// WithMaxResponseSize limits the size of the responses read from the server. Reading a larger response fails with
+// ErrResponseTooLarge. The default is DefaultMaxResponseSize.
+func WithMaxResponseSize(size int64) Option {
+	return func(c *Client) { c.maxResponseSize = size }
+}
+
+// NewClient returns a Client for the provided API key, configured with the provided options.
+//
+// NewClient returns an error wrapping ErrInvalidKey if the key isn't a valid SolarEdge API key, or an error if
+// the base URL isn't a valid http or https URL, or if the timeout, number of retries or maximum
+// response size is negative.
+func NewClient(key string, opts ...Option) (*Client, error) {
+	if !keyFormat.MatchString(key) {
+		return nil, fmt.Errorf("%w: must be 32 uppercase letters or digits", ErrInvalidKey)
+	}
+	c := Client{SiteKey: key}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.baseURL != "" {
+		u, err := url.Parse(c.baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base url: %w", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid base url %q: must be an absolute http or https url", c.baseURL)
+		}
+		if u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("invalid base url %q: must not contain a query or fragment", c.baseURL)
+		}
+	}
+	if c.maxResponseSize < 0 {
+		return nil, fmt.Errorf("invalid max response size %d", c.maxResponseSize)
+	}
+	if c.retries < 0 {
+		return nil, fmt.Errorf("invalid number of retries %d", c.retries)
+	}
+	if c.timeout < 0 {
+		return nil, fmt.Errorf("invalid timeout %s", c.timeout)
+	}
+	return &c, nil
+}
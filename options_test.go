@@ -0,0 +1,134 @@
+package solaredge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const validKey = "ABCDEFGHIJKLMNOPQRSTUVWXYZ012345"
+
+func TestNewClient_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		opts    []Option
+		wantErr bool
+	}{
+		{name: "valid", key: validKey},
+		{name: "valid base url", key: validKey, opts: []Option{WithBaseURL("http://localhost:8080/")}},
+		{name: "short key", key: "ABC", wantErr: true},
+		{name: "lowercase key", key: strings.ToLower(validKey), wantErr: true},
+		{name: "relative base url", key: validKey, opts: []Option{WithBaseURL("/api")}, wantErr: true},
+		{name: "invalid scheme", key: validKey, opts: []Option{WithBaseURL("ftp://localhost")}, wantErr: true},
+		{name: "query in base url", key: validKey, opts: []Option{WithBaseURL("http://localhost?foo=bar")}, wantErr: true},
+		{name: "invalid base url", key: validKey, opts: []Option{WithBaseURL("http://local host")}, wantErr: true},
+		{name: "negative timeout", key: validKey, opts: []Option{WithTimeout(-time.Second)}, wantErr: true},
+		{name: "negative max response size", key: validKey, opts: []Option{WithMaxResponseSize(-1)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.key, tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := NewClient("invalid"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+}
+
+type countingLimiter struct{ count int }
+
+func (l *countingLimiter) Wait(_ context.Context) error {
+	l.count++
+	return nil
+}
+
+func TestNewClient_Options(t *testing.T) {
+	var userAgent, version string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			return
+		}
+		userAgent = r.Header.Get("User-Agent")
+		version = r.URL.Query().Get("version")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"version":{"release":"1.0.0"}}`))
+	}))
+	defer s.Close()
+
+	var logs bytes.Buffer
+	var limiter countingLimiter
+	c, err := NewClient(validKey,
+		WithBaseURL(s.URL+"/"),
+		WithHTTPClient(s.Client()),
+		WithUserAgent("test/1.0"),
+		WithAPIVersion("2.0.0"),
+		WithTimeout(100*time.Millisecond),
+		WithLimiter(&limiter),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if _, err = c.GetCurrentAPIVersion(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if userAgent != "test/1.0" {
+		t.Errorf("got user agent %q, want %q", userAgent, "test/1.0")
+	}
+	if version != "2.0.0" {
+		t.Errorf("got version %q, want %q", version, "2.0.0")
+	}
+	if limiter.count != 1 {
+		t.Errorf("got %d limiter calls, want 1", limiter.count)
+	}
+	if !strings.Contains(logs.String(), "path=/version/current status=200") {
+		t.Errorf("call not logged: %s", logs.String())
+	}
+	if strings.Contains(logs.String(), validKey) {
+		t.Errorf("api key logged: %s", logs.String())
+	}
+
+	if _, err = call[GetCurrentAPIVersionResponse](ctx, c, "/slow", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
+
+func TestClient_LogRedactsKey(t *testing.T) {
+	s := httptest.NewServer(http.NotFoundHandler())
+	s.Close()
+
+	var logs bytes.Buffer
+	c, err := NewClient(validKey,
+		WithBaseURL(s.URL),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.GetCurrentAPIVersion(context.Background())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(logs.String(), "api call failed") {
+		t.Errorf("failure not logged: %s", logs.String())
+	}
+	for _, text := range []string{logs.String(), err.Error()} {
+		if strings.Contains(text, validKey) {
+			t.Errorf("api key leaked: %s", text)
+		}
+	}
+}
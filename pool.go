@@ -0,0 +1,405 @@
+package solaredge
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownSite is returned by a Pool if none of its Clients has access to the site.
+	ErrUnknownSite = errors.New("no api key has access to the site")
+	// ErrQuotaExhausted is returned by a Pool if all Clients that have access to the site have used their daily quota.
+	ErrQuotaExhausted = errors.New("daily quota exhausted")
+)
+
+// Pool routes calls to one of several Clients, each using a different API key, e.g. an account key and a number
+// of site keys. Pool implements API, so it can be used wherever a Client is used through the API interface.
+//
+// Pool discovers which sites each key has access to by calling GetSites for each Client. This happens on the first
+// call for a site, or when calling Discover. After that, the Pool discovers the sites again, at most once per
+// DiscoveryBackoff, for Clients for which GetSites failed, for Clients that were denied access to a site, and for all
+// Clients when a call is for a site that no Client has access to. This picks up sites that were added to a key later.
+//
+// A call for a site is sent with the Client that has the most remaining quota (see WithQuotaTracker), skipping Clients
+// that have used their daily quota. If the server denies access (status 403), the Pool stops using that Client for
+// the site until the next discovery and retries the call with the next Client. If the server reports that the key's
+// quota is exhausted (status 429), the Pool stops using that Client until the quota resets and retries the call
+// with the next Client. Without a QuotaTracker, the quota is considered to reset at midnight UTC.
+type Pool struct {
+	lastErr     error
+	lastAttempt time.Time
+	sites       map[int][]*Client
+	// exhausted holds the Clients whose quota is exhausted, with the time at which their quota resets.
+	exhausted map[*Client]time.Time
+	details   Sites
+	clients   []*Client
+	// pending holds the Clients that need to be discovered again: GetSites failed for them, or they were denied
+	// access to a site.
+	pending []*Client
+	// DiscoveryBackoff is the minimum time between two discoveries after the first one, e.g. to retry a Client for
+	// which GetSites failed. Defaults to 5 minutes.
+	DiscoveryBackoff time.Duration
+	lock             sync.Mutex
+	discovered       bool
+}
+
+const defaultDiscoveryBackoff = 5 * time.Minute
+
+// NewPool returns a Pool for the provided Clients.
+func NewPool(clients ...*Client) *Pool {
+	return &Pool{clients: clients}
+}
+
+var _ API = &Pool{}
+
+// Discover calls GetSites for each Client to determine which sites each API key has access to. If a call fails,
+// Discover continues with the next Client and returns all errors once done.
+func (p *Pool) Discover(ctx context.Context) error {
+	p.lock.Lock()
+	p.lastAttempt = time.Now()
+	p.lock.Unlock()
+	return p.discover(ctx, p.clients, true)
+}
+
+// discover calls GetSites for the Clients and records the sites they have access to. If reset is true, the sites
+// discovered before are forgotten.
+func (p *Pool) discover(ctx context.Context, clients []*Client, reset bool) error {
+	responses := make(map[*Client]GetSitesResponse)
+	var failed []*Client
+	var errs []error
+	for _, c := range clients {
+		resp, err := c.GetSites(ctx)
+		if err != nil {
+			failed = append(failed, c)
+			errs = append(errs, err)
+			continue
+		}
+		responses[c] = resp
+	}
+	err := errors.Join(errs...)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.lastErr = err
+	p.pending = slices.DeleteFunc(p.pending, func(c *Client) bool { return slices.Contains(clients, c) })
+	for _, c := range failed {
+		p.pending = appendUnique(p.pending, c)
+	}
+	if len(responses) == 0 {
+		return err
+	}
+	if reset || p.sites == nil {
+		p.sites = make(map[int][]*Client)
+		p.details = nil
+	}
+	// process the responses in the order of the Pool's Clients, so sites are reported in a predictable order
+	for _, c := range p.clients {
+		resp, ok := responses[c]
+		if !ok {
+			continue
+		}
+		for _, site := range resp.Sites.Site {
+			if _, ok := p.sites[site.Id]; !ok {
+				p.details = append(p.details, site)
+			}
+			p.sites[site.Id] = appendUnique(p.sites[site.Id], c)
+		}
+	}
+	p.discovered = true
+	return err
+}
+
+func appendUnique(clients []*Client, c *Client) []*Client {
+	if slices.Contains(clients, c) {
+		return clients
+	}
+	return append(clients, c)
+}
+
+// candidates returns the Clients with access to the site, ordered by remaining quota. If no Client has access
+// to the site, candidates discovers the sites of all Clients again, once DiscoveryBackoff has passed.
+func (p *Pool) candidates(ctx context.Context, id int) ([]*Client, error) {
+	if err := p.retryDiscovery(ctx, false); err != nil {
+		return nil, err
+	}
+	clients := p.clientsFor(id)
+	if len(clients) == 0 {
+		if err := p.retryDiscovery(ctx, true); err != nil {
+			return nil, err
+		}
+		clients = p.clientsFor(id)
+	}
+	if len(clients) == 0 {
+		return nil, ErrUnknownSite
+	}
+	clients = slices.DeleteFunc(clients, func(c *Client) bool { return !p.hasQuota(c) })
+	if len(clients) == 0 {
+		return nil, ErrQuotaExhausted
+	}
+	slices.SortStableFunc(clients, func(a, b *Client) int { return cmp.Compare(remaining(b), remaining(a)) })
+	return clients, nil
+}
+
+func (p *Pool) clientsFor(id int) []*Client {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return slices.Clone(p.sites[id])
+}
+
+// retryDiscovery discovers the sites of the pending Clients, or of all Clients if all is true, at most once per
+// DiscoveryBackoff. It returns an error if no Client has been discovered.
+func (p *Pool) retryDiscovery(ctx context.Context, all bool) error {
+	p.lock.Lock()
+	clients := p.pending
+	if all || (!p.discovered && p.lastAttempt.IsZero()) {
+		clients = p.clients
+	}
+	due := time.Since(p.lastAttempt) >= cmp.Or(p.DiscoveryBackoff, defaultDiscoveryBackoff)
+	if len(clients) > 0 && due {
+		p.lastAttempt = time.Now()
+	}
+	clients = slices.Clone(clients)
+	p.lock.Unlock()
+
+	if len(clients) > 0 && due {
+		_ = p.discover(ctx, clients, false)
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if !p.discovered {
+		return p.lastErr
+	}
+	return nil
+}
+
+func (p *Pool) isDiscovered() bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.discovered
+}
+
+// remaining returns the Client's remaining quota. Clients without a QuotaTracker are considered to have quota left.
+func remaining(c *Client) int {
+	if usage, ok := c.Quota(); ok {
+		return usage.Remaining()
+	}
+	return math.MaxInt
+}
+
+// hasQuota returns true if the Client has quota left: its QuotaTracker reports remaining calls and the server hasn't
+// reported that its quota is exhausted since the last reset.
+func (p *Pool) hasQuota(c *Client) bool {
+	if remaining(c) == 0 {
+		return false
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	reset, ok := p.exhausted[c]
+	if ok && !time.Now().Before(reset) {
+		delete(p.exhausted, c)
+		ok = false
+	}
+	return !ok
+}
+
+// exhaust stops using the Client until its quota resets, after the server reported that its quota is exhausted.
+func (p *Pool) exhaust(c *Client) {
+	reset := nextMidnightUTC(time.Now())
+	if usage, ok := c.Quota(); ok {
+		reset = usage.Reset
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.exhausted == nil {
+		p.exhausted = make(map[*Client]time.Time)
+	}
+	p.exhausted[c] = reset
+}
+
+func nextMidnightUTC(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+// forget stops using the Client for the site, after the server denied access. The Client is discovered again
+// on a later call, once DiscoveryBackoff has passed.
+func (p *Pool) forget(id int, c *Client) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.sites[id] = slices.DeleteFunc(p.sites[id], func(client *Client) bool { return client == c })
+	p.pending = appendUnique(p.pending, c)
+}
+
+func statusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// route performs the call for the site with each candidate Client, until one succeeds or fails with an error
+// other than status 403 or 429.
+func route[T any](ctx context.Context, p *Pool, id int, f func(*Client) (T, error)) (T, error) {
+	var response T
+	clients, err := p.candidates(ctx, id)
+	if err != nil {
+		return response, err
+	}
+	for _, c := range clients {
+		response, err = f(c)
+		switch statusCode(err) {
+		case http.StatusForbidden:
+			p.forget(id, c)
+		case http.StatusTooManyRequests:
+			p.exhaust(c)
+		default:
+			return response, err
+		}
+	}
+	return response, err
+}
+
+// anyClient performs a call that isn't for a site with the first Client that has quota left, failing over on
+// status 403 and 429.
+func anyClient[T any](p *Pool, f func(*Client) (T, error)) (T, error) {
+	var response T
+	err := ErrQuotaExhausted
+	for _, c := range p.clients {
+		if !p.hasQuota(c) {
+			continue
+		}
+		response, err = f(c)
+		switch statusCode(err) {
+		case http.StatusForbidden:
+		case http.StatusTooManyRequests:
+			p.exhaust(c)
+		default:
+			return response, err
+		}
+	}
+	return response, err
+}
+
+// GetSites returns the sites of all API keys. Each site is reported once. GetSites refreshes the Pool's mapping
+// of sites to API keys.
+//
+// If GetSites fails for some of the Clients, the response holds the sites of the other Clients and the error
+// reports the failed calls. Callers should therefore check the response even if the error is not nil.
+func (p *Pool) GetSites(ctx context.Context) (GetSitesResponse, error) {
+	var resp GetSitesResponse
+	err := p.Discover(ctx)
+	if err != nil && !p.isDiscovered() {
+		return resp, err
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	resp.Sites.Site = slices.Clone(p.details)
+	resp.Sites.Count = len(resp.Sites.Site)
+	return resp, err
+}
+
+// GetSiteDetails calls Client.GetSiteDetails with a Client that has access to the site.
+func (p *Pool) GetSiteDetails(ctx context.Context, id int) (GetSiteDetailsResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetSiteDetailsResponse, error) { return c.GetSiteDetails(ctx, id) })
+}
+
+// GetDataPeriod calls Client.GetDataPeriod with a Client that has access to the site.
+func (p *Pool) GetDataPeriod(ctx context.Context, id int) (GetDataPeriodResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetDataPeriodResponse, error) { return c.GetDataPeriod(ctx, id) })
+}
+
+// GetEnergyMeasurements calls Client.GetEnergyMeasurements with a Client that has access to the site.
+func (p *Pool) GetEnergyMeasurements(ctx context.Context, id int, timeUnit TimeUnit, startDate time.Time, endDate time.Time) (GetEnergyMeasurementsResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetEnergyMeasurementsResponse, error) {
+		return c.GetEnergyMeasurements(ctx, id, timeUnit, startDate, endDate)
+	})
+}
+
+// GetEnergyForTimeFrame calls Client.GetEnergyForTimeFrame with a Client that has access to the site.
+func (p *Pool) GetEnergyForTimeFrame(ctx context.Context, id int, startDate, endDate time.Time) (GetEnergyForTimeframeResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetEnergyForTimeframeResponse, error) {
+		return c.GetEnergyForTimeFrame(ctx, id, startDate, endDate)
+	})
+}
+
+// GetPowerMeasurements calls Client.GetPowerMeasurements with a Client that has access to the site.
+func (p *Pool) GetPowerMeasurements(ctx context.Context, id int, startTime, endTime time.Time) (GetPowerMeasurementsResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetPowerMeasurementsResponse, error) {
+		return c.GetPowerMeasurements(ctx, id, startTime, endTime)
+	})
+}
+
+// GetPowerOverview calls Client.GetPowerOverview with a Client that has access to the site.
+func (p *Pool) GetPowerOverview(ctx context.Context, id int) (GetPowerOverviewResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetPowerOverviewResponse, error) { return c.GetPowerOverview(ctx, id) })
+}
+
+// GetPowerDetails calls Client.GetPowerDetails with a Client that has access to the site.
+func (p *Pool) GetPowerDetails(ctx context.Context, id int, start, end time.Time) (GetPowerDetailsResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetPowerDetailsResponse, error) { return c.GetPowerDetails(ctx, id, start, end) })
+}
+
+// GetEnergyDetails calls Client.GetEnergyDetails with a Client that has access to the site.
+func (p *Pool) GetEnergyDetails(ctx context.Context, id int, timeUnit TimeUnit, startTime, endTime time.Time) (GetEnergyDetailsResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetEnergyDetailsResponse, error) {
+		return c.GetEnergyDetails(ctx, id, timeUnit, startTime, endTime)
+	})
+}
+
+// GetPowerFlow calls Client.GetPowerFlow with a Client that has access to the site.
+func (p *Pool) GetPowerFlow(ctx context.Context, id int) (GetPowerFlowResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetPowerFlowResponse, error) { return c.GetPowerFlow(ctx, id) })
+}
+
+// GetStorageData calls Client.GetStorageData with a Client that has access to the site.
+func (p *Pool) GetStorageData(ctx context.Context, id int, startTime, endTime time.Time) (GetStorageDataResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetStorageDataResponse, error) {
+		return c.GetStorageData(ctx, id, startTime, endTime)
+	})
+}
+
+// GetEnvBenefits calls Client.GetEnvBenefits with a Client that has access to the site.
+func (p *Pool) GetEnvBenefits(ctx context.Context, id int) (GetEnvBenefitsResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetEnvBenefitsResponse, error) { return c.GetEnvBenefits(ctx, id) })
+}
+
+// GetComponents calls Client.GetComponents with a Client that has access to the site.
+func (p *Pool) GetComponents(ctx context.Context, id int) (GetComponentsResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetComponentsResponse, error) { return c.GetComponents(ctx, id) })
+}
+
+// GetInventory calls Client.GetInventory with a Client that has access to the site.
+func (p *Pool) GetInventory(ctx context.Context, id int) (GetInventoryResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetInventoryResponse, error) { return c.GetInventory(ctx, id) })
+}
+
+// GetInverterTechnicalData calls Client.GetInverterTechnicalData with a Client that has access to the site.
+func (p *Pool) GetInverterTechnicalData(ctx context.Context, id int, serialNr string, startTime, endTime time.Time) (GetInverterTechnicalDataResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetInverterTechnicalDataResponse, error) {
+		return c.GetInverterTechnicalData(ctx, id, serialNr, startTime, endTime)
+	})
+}
+
+// GetEquipmentChangeLog calls Client.GetEquipmentChangeLog with a Client that has access to the site.
+func (p *Pool) GetEquipmentChangeLog(ctx context.Context, id int, serialNr string) (GetEquipmentChangeLogResponse, error) {
+	return route(ctx, p, id, func(c *Client) (GetEquipmentChangeLogResponse, error) {
+		return c.GetEquipmentChangeLog(ctx, id, serialNr)
+	})
+}
+
+// GetCurrentAPIVersion calls Client.GetCurrentAPIVersion with the first Client that has quota left.
+func (p *Pool) GetCurrentAPIVersion(ctx context.Context) (GetCurrentAPIVersionResponse, error) {
+	return anyClient(p, func(c *Client) (GetCurrentAPIVersionResponse, error) { return c.GetCurrentAPIVersion(ctx) })
+}
+
+// GetSupportedAPIVersions calls Client.GetSupportedAPIVersions with the first Client that has quota left.
+func (p *Pool) GetSupportedAPIVersions(ctx context.Context) (GetSupportedAPIVersionsResponse, error) {
+	return anyClient(p, func(c *Client) (GetSupportedAPIVersionsResponse, error) { return c.GetSupportedAPIVersions(ctx) })
+}
@@ -0,0 +1,231 @@
+package solaredge_test
+
+import (
+	"context"
+	"errors"
+	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/solaredgetest"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	site := func(id int, name string) solaredgetest.Site {
+		var details solaredge.SiteDetails
+		details.Id = id
+		details.Name = name
+		return solaredgetest.Site{Details: details}
+	}
+	// the account key has access to sites 1 and 2. The site key only has access to site 2.
+	account := solaredgetest.NewServer("ACCOUNT", site(1, "home"), site(2, "office"))
+	defer account.Close()
+	siteKey := solaredgetest.NewServer("SITE", site(2, "office"))
+	defer siteKey.Close()
+
+	pool := solaredge.NewPool(
+		&solaredge.Client{SiteKey: "ACCOUNT", HTTPClient: account.Client()},
+		&solaredge.Client{SiteKey: "SITE", HTTPClient: siteKey.Client()},
+	)
+	ctx := context.Background()
+
+	resp, err := pool.GetSites(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Sites.Count != 2 {
+		t.Errorf("got %d sites, want 2", resp.Sites.Count)
+	}
+
+	if _, err = pool.GetSiteDetails(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pool.GetSiteDetails(ctx, 3); !errors.Is(err, solaredge.ErrUnknownSite) {
+		t.Errorf("expected ErrUnknownSite, got %v", err)
+	}
+
+	// the account key loses access to site 2: the pool fails over to the site key
+	account.Fail("/site/2/overview", solaredgetest.Failure{StatusCode: http.StatusForbidden, Message: "Invalid token"})
+	if _, err = pool.GetPowerOverview(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := siteKey.Requests(2); got != 1 {
+		t.Errorf("got %d requests with the site key, want 1", got)
+	}
+	// further calls for site 2 go to the site key directly
+	if _, err = pool.GetPowerFlow(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := siteKey.Requests(2); got != 2 {
+		t.Errorf("got %d requests with the site key, want 2", got)
+	}
+
+	if _, err = pool.GetCurrentAPIVersion(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPool_Quota(t *testing.T) {
+	var details solaredge.SiteDetails
+	details.Id = 1
+	const accountKey = "ACCOUNT0000000000000000000000000"
+	const siteKeyValue = "SITE0000000000000000000000000000"
+	account := solaredgetest.NewServer(accountKey, solaredgetest.Site{Details: details})
+	defer account.Close()
+	siteKey := solaredgetest.NewServer(siteKeyValue, solaredgetest.Site{Details: details})
+	defer siteKey.Close()
+
+	accountTracker, _ := solaredge.OpenQuotaTracker("")
+	accountTracker.Limit = 2
+	siteTracker, _ := solaredge.OpenQuotaTracker("")
+	siteTracker.Limit = 3
+	accountClient, err := solaredge.NewClient(accountKey, solaredge.WithHTTPClient(account.Client()), solaredge.WithQuotaTracker(accountTracker))
+	if err != nil {
+		t.Fatal(err)
+	}
+	siteClient, err := solaredge.NewClient(siteKeyValue, solaredge.WithHTTPClient(siteKey.Client()), solaredge.WithQuotaTracker(siteTracker))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := solaredge.NewPool(accountClient, siteClient)
+	ctx := context.Background()
+
+	// discovery uses one call of each key. After that, the site key has the most quota left, until both are used up.
+	for range 3 {
+		if _, err = pool.GetPowerFlow(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = pool.GetPowerFlow(ctx, 1); !errors.Is(err, solaredge.ErrQuotaExhausted) {
+		t.Errorf("expected ErrQuotaExhausted, got %v", err)
+	}
+	if got := account.Requests(1); got != 1 {
+		t.Errorf("got %d requests with the account key, want 1", got)
+	}
+	if got := siteKey.Requests(1); got != 2 {
+		t.Errorf("got %d requests with the site key, want 2", got)
+	}
+}
+
+func TestPool_Discovery(t *testing.T) {
+	site := func(id int) solaredgetest.Site {
+		var details solaredge.SiteDetails
+		details.Id = id
+		return solaredgetest.Site{Details: details}
+	}
+	account := solaredgetest.NewServer("ACCOUNT", site(1), site(2))
+	defer account.Close()
+	siteKey := solaredgetest.NewServer("SITE", site(2))
+	defer siteKey.Close()
+	pool := solaredge.NewPool(
+		&solaredge.Client{SiteKey: "ACCOUNT", HTTPClient: account.Client()},
+		&solaredge.Client{SiteKey: "SITE", HTTPClient: siteKey.Client()},
+	)
+	pool.DiscoveryBackoff = time.Hour
+	ctx := context.Background()
+
+	// discovery fails for both keys: calls fail without calling GetSites again until the backoff has passed
+	account.Fail("/sites/list", solaredgetest.Failure{StatusCode: http.StatusInternalServerError})
+	siteKey.Fail("/sites/list", solaredgetest.Failure{StatusCode: http.StatusInternalServerError})
+	for range 2 {
+		if _, err := pool.GetPowerFlow(ctx, 1); err == nil {
+			t.Fatal("expected an error")
+		}
+	}
+	if got := account.Requests(0); got != 1 {
+		t.Errorf("got %d discovery requests, want 1", got)
+	}
+
+	// the account key recovers
+	account.ClearFailures()
+	pool.DiscoveryBackoff = time.Nanosecond
+	if _, err := pool.GetPowerFlow(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// the site key is retried on a later call
+	siteKey.ClearFailures()
+	if _, err := pool.GetPowerFlow(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := siteKey.Requests(0); got != 3 {
+		t.Errorf("got %d discovery requests for the site key, want 3", got)
+	}
+	if got := account.Requests(0); got != 2 {
+		t.Errorf("got %d discovery requests for the account key, want 2", got)
+	}
+
+	// the account key's quota is exhausted: the pool fails over to the site key
+	account.Fail("/site/2/currentPowerFlow", solaredgetest.Failure{StatusCode: http.StatusTooManyRequests})
+	if _, err := pool.GetPowerFlow(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := siteKey.Requests(2); got != 1 {
+		t.Errorf("got %d requests with the site key, want 1", got)
+	}
+	// the pool doesn't use the account key again until its quota resets
+	account.ClearFailures()
+	if _, err := pool.GetPowerFlow(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := account.Requests(2); got != 1 {
+		t.Errorf("got %d requests with the account key, want 1", got)
+	}
+	if _, err := pool.GetPowerFlow(ctx, 1); !errors.Is(err, solaredge.ErrQuotaExhausted) {
+		t.Errorf("expected ErrQuotaExhausted, got %v", err)
+	}
+}
+
+func TestPool_Rediscovery(t *testing.T) {
+	site := func(id int) solaredgetest.Site {
+		var details solaredge.SiteDetails
+		details.Id = id
+		return solaredgetest.Site{Details: details}
+	}
+	account := solaredgetest.NewServer("ACCOUNT", site(1))
+	defer account.Close()
+	siteKey := solaredgetest.NewServer("SITE", site(2))
+	defer siteKey.Close()
+	pool := solaredge.NewPool(
+		&solaredge.Client{SiteKey: "ACCOUNT", HTTPClient: account.Client()},
+		&solaredge.Client{SiteKey: "SITE", HTTPClient: siteKey.Client()},
+	)
+	ctx := context.Background()
+
+	// GetSites fails for the site key: the response holds the sites of the account key, together with the error
+	siteKey.Fail("/sites/list", solaredgetest.Failure{StatusCode: http.StatusInternalServerError, Times: 1})
+	resp, err := pool.GetSites(ctx)
+	if err == nil {
+		t.Error("expected an error")
+	}
+	if resp.Sites.Count != 1 || resp.Sites.Site[0].Id != 1 {
+		t.Errorf("got %v, want site 1", resp.Sites.Site)
+	}
+
+	// within the backoff, a call for an unknown site doesn't discover the sites again
+	account.AddSite(site(3))
+	if _, err = pool.GetPowerFlow(ctx, 3); !errors.Is(err, solaredge.ErrUnknownSite) {
+		t.Errorf("expected ErrUnknownSite, got %v", err)
+	}
+	if got := account.Requests(0); got != 1 {
+		t.Errorf("got %d discovery requests, want 1", got)
+	}
+
+	// once the backoff has passed, a call for an unknown site discovers the sites of all keys again
+	pool.DiscoveryBackoff = time.Nanosecond
+	if _, err = pool.GetPowerFlow(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pool.GetPowerFlow(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// the account key is denied access to site 1: the next discovery brings it back
+	account.Fail("/site/1/currentPowerFlow", solaredgetest.Failure{StatusCode: http.StatusForbidden, Times: 1})
+	if _, err = pool.GetPowerFlow(ctx, 1); err == nil {
+		t.Error("expected an error")
+	}
+	if _, err = pool.GetPowerFlow(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,232 @@
+package solaredge
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/clambin/solaredge/v2/internal/atomicfile"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultDailyQuota is the number of requests per day the SolarEdge API allows, both per API key and per site.
+const DefaultDailyQuota = 300
+
+// QuotaUsage reports the number of calls made today with an API key, or for a site.
+type QuotaUsage struct {
+	// Reset is the time at which the quota resets.
+	Reset time.Time
+	// KeyID identifies the API key. See KeyID. Empty if the usage is for a site.
+	KeyID string
+	// SiteID is the ID of the site. Zero if the usage is for an API key.
+	SiteID int
+	// Used is the number of calls made today.
+	Used int
+	// Limit is the number of calls allowed per day.
+	Limit int
+}
+
+// Remaining returns the number of calls left for today.
+func (u QuotaUsage) Remaining() int {
+	return max(0, u.Limit-u.Used)
+}
+
+// KeyID returns an ID for the API key that doesn't reveal the key. QuotaTracker uses it to identify the key, both
+// in QuotaUsage and in its file.
+func KeyID(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:4])
+}
+
+// QuotaTracker counts the calls made with each API key and for each site, so applications can stay within
+// SolarEdge's daily quota. The counts reset at midnight in Location. Use WithQuotaTracker to count a Client's calls.
+// Several Clients may share a QuotaTracker.
+//
+// If the QuotaTracker has a file, the counts are written to the file after each call, so they survive a restart
+// of the application.
+type QuotaTracker struct {
+	// OnThreshold is called when a call makes the usage of an API key or site cross one of the Thresholds.
+	// OnThreshold must not call the Client.
+	OnThreshold func(usage QuotaUsage, threshold float64)
+	// Location determines when the quota resets: at midnight in Location. SolarEdge resets the quota at midnight
+	// server time. The default is UTC.
+	Location *time.Location
+	// now returns the current time. Used for testing.
+	now    func() time.Time
+	counts quotaCounts
+	path   string
+	// Thresholds are the fractions of Limit at which OnThreshold is called. The default is 0.8 and 1.
+	Thresholds []float64
+	// Limit is the number of calls allowed per day. The default is DefaultDailyQuota.
+	Limit int
+	lock  sync.Mutex
+}
+
+// quotaCounts is the content of the QuotaTracker's file.
+type quotaCounts struct {
+	Keys  map[string]int `json:"keys"`
+	Sites map[string]int `json:"sites"`
+	Day   string         `json:"day"`
+}
+
+// OpenQuotaTracker returns a QuotaTracker that stores its counts in the specified file. If the file doesn't exist,
+// it is created after the first call. If path is empty, the counts are kept in memory only.
+func OpenQuotaTracker(path string) (*QuotaTracker, error) {
+	t := QuotaTracker{path: path}
+	if path == "" {
+		return &t, nil
+	}
+	body, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(body, &t.counts); err != nil {
+		return nil, fmt.Errorf("quota %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+// KeyQuota returns today's usage of the API key.
+func (t *QuotaTracker) KeyQuota(key string) QuotaUsage {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.rollover()
+	id := KeyID(key)
+	return t.usage(id, 0, t.counts.Keys[id])
+}
+
+// SiteQuota returns today's usage of the site.
+func (t *QuotaTracker) SiteQuota(id int) QuotaUsage {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.rollover()
+	return t.usage("", id, t.counts.Sites[strconv.Itoa(id)])
+}
+
+// record counts a call made with the key for the site. siteID is zero for calls that aren't for a site.
+func (t *QuotaTracker) record(key string, siteID int) error {
+	type crossing struct {
+		usage     QuotaUsage
+		threshold float64
+	}
+	var crossings []crossing
+
+	t.lock.Lock()
+	t.rollover()
+	id := KeyID(key)
+	t.counts.Keys = increment(t.counts.Keys, id)
+	for _, threshold := range t.crossed(t.counts.Keys[id]) {
+		crossings = append(crossings, crossing{usage: t.usage(id, 0, t.counts.Keys[id]), threshold: threshold})
+	}
+	if siteID != 0 {
+		site := strconv.Itoa(siteID)
+		t.counts.Sites = increment(t.counts.Sites, site)
+		for _, threshold := range t.crossed(t.counts.Sites[site]) {
+			crossings = append(crossings, crossing{usage: t.usage("", siteID, t.counts.Sites[site]), threshold: threshold})
+		}
+	}
+	err := t.save()
+	t.lock.Unlock()
+
+	if t.OnThreshold != nil {
+		for _, c := range crossings {
+			t.OnThreshold(c.usage, c.threshold)
+		}
+	}
+	return err
+}
+
+func increment(counts map[string]int, key string) map[string]int {
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	counts[key]++
+	return counts
+}
+
+// crossed returns the thresholds crossed when the count reached used.
+func (t *QuotaTracker) crossed(used int) []float64 {
+	limit := float64(t.limit())
+	thresholds := t.Thresholds
+	if len(thresholds) == 0 {
+		thresholds = defaultThresholds
+	}
+	var crossed []float64
+	for _, threshold := range thresholds {
+		if float64(used-1) < threshold*limit && float64(used) >= threshold*limit {
+			crossed = append(crossed, threshold)
+		}
+	}
+	return crossed
+}
+
+var defaultThresholds = []float64{0.8, 1}
+
+func (t *QuotaTracker) limit() int {
+	return cmp.Or(t.Limit, DefaultDailyQuota)
+}
+
+func (t *QuotaTracker) usage(keyID string, siteID int, used int) QuotaUsage {
+	return QuotaUsage{KeyID: keyID, SiteID: siteID, Used: used, Limit: t.limit(), Reset: t.reset()}
+}
+
+func (t *QuotaTracker) today() time.Time {
+	now := time.Now
+	if t.now != nil {
+		now = t.now
+	}
+	today := now().In(cmp.Or(t.Location, time.UTC))
+	return time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+}
+
+func (t *QuotaTracker) reset() time.Time {
+	return t.today().AddDate(0, 0, 1)
+}
+
+// rollover clears the counts if they are for an earlier day. The caller must hold the lock.
+func (t *QuotaTracker) rollover() {
+	if today := t.today().Format(time.DateOnly); t.counts.Day != today {
+		t.counts = quotaCounts{Day: today}
+	}
+}
+
+// save writes the counts to the file. The caller must hold the lock.
+func (t *QuotaTracker) save() error {
+	if t.path == "" {
+		return nil
+	}
+	body, err := json.MarshalIndent(t.counts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(t.path, func(w io.Writer) error {
+		_, err := w.Write(body)
+		return err
+	})
+}
+
+// Quota returns today's usage of the Client's API key. Returns false if the Client has no QuotaTracker.
+func (c *Client) Quota() (QuotaUsage, bool) {
+	if c.quota == nil {
+		return QuotaUsage{}, false
+	}
+	return c.quota.KeyQuota(c.SiteKey), true
+}
+
+// Quota returns today's usage of the site. Returns false if the Client has no QuotaTracker.
+func (s *SiteClient) Quota() (QuotaUsage, bool) {
+	if s.client.quota == nil {
+		return QuotaUsage{}, false
+	}
+	return s.client.quota.SiteQuota(s.id), true
+}
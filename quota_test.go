@@ -0,0 +1,112 @@
+package solaredge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQuotaTracker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	tracker, err := OpenQuotaTracker(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	brussels, _ := time.LoadLocation("Europe/Brussels")
+	now := time.Date(2024, time.June, 1, 23, 0, 0, 0, brussels)
+	tracker.now = func() time.Time { return now }
+	tracker.Location = brussels
+	tracker.Limit = 10
+	tracker.Thresholds = []float64{0.5, 1}
+	var crossed []QuotaUsage
+	tracker.OnThreshold = func(usage QuotaUsage, threshold float64) {
+		if threshold != float64(usage.Used)/float64(usage.Limit) {
+			t.Errorf("threshold %v crossed at %d/%d", threshold, usage.Used, usage.Limit)
+		}
+		crossed = append(crossed, usage)
+	}
+
+	for range 5 {
+		if err = tracker.record("KEY", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = tracker.record("KEY", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := tracker.KeyQuota("KEY"); got.Used != 6 || got.Remaining() != 4 || got.KeyID != KeyID("KEY") {
+		t.Errorf("unexpected key usage: %+v", got)
+	}
+	site := tracker.SiteQuota(1)
+	if site.Used != 5 || site.SiteID != 1 {
+		t.Errorf("unexpected site usage: %+v", site)
+	}
+	if want := time.Date(2024, time.June, 2, 0, 0, 0, 0, brussels); !site.Reset.Equal(want) {
+		t.Errorf("got reset %v, want %v", site.Reset, want)
+	}
+	if len(crossed) != 2 {
+		t.Errorf("got %d crossings, want 2: %+v", len(crossed), crossed)
+	}
+
+	// counts survive a restart
+	restarted, err := OpenQuotaTracker(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restarted.now = tracker.now
+	restarted.Location = brussels
+	if got := restarted.KeyQuota("KEY").Used; got != 6 {
+		t.Errorf("got %d calls after restart, want 6", got)
+	}
+
+	// counts reset at midnight
+	now = now.Add(time.Hour)
+	if got := restarted.KeyQuota("KEY").Used; got != 0 {
+		t.Errorf("got %d calls after midnight, want 0", got)
+	}
+}
+
+func TestOpenQuotaTracker_Invalid(t *testing.T) {
+	if _, err := OpenQuotaTracker(filepath.Join("testdata", "missing", "quota.json")); err != nil {
+		t.Errorf("missing file: unexpected error %v", err)
+	}
+	if _, err := OpenQuotaTracker("quota_test.go"); err == nil {
+		t.Error("expected an error for an invalid file")
+	}
+}
+
+func TestClient_Quota(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer s.Close()
+
+	c, err := NewClient(validKey, WithBaseURL(s.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Quota(); ok {
+		t.Error("expected no quota without a QuotaTracker")
+	}
+
+	tracker, _ := OpenQuotaTracker("")
+	if c, err = NewClient(validKey, WithBaseURL(s.URL), WithQuotaTracker(tracker)); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	_, _ = c.GetSites(ctx)
+	_, _ = c.GetSiteDetails(ctx, 1)
+	_, _ = c.Site(2).GetPowerOverview(ctx)
+
+	if usage, ok := c.Quota(); !ok || usage.Used != 3 || usage.Limit != DefaultDailyQuota {
+		t.Errorf("unexpected key usage: %+v", usage)
+	}
+	if usage, ok := c.Site(1).Quota(); !ok || usage.Used != 1 {
+		t.Errorf("unexpected site usage: %+v", usage)
+	}
+}
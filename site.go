@@ -3,7 +3,6 @@ package solaredge
 import (
 	"context"
 	"net/url"
-	"strconv"
 	"time"
 )
 
@@ -53,7 +52,7 @@ func (s Sites) FindByName(name string) (SiteDetails, bool) {
 
 // GetSiteDetails returns the site details, such as name, location, status, etc.
 func (c *Client) GetSiteDetails(ctx context.Context, id int) (GetSiteDetailsResponse, error) {
-	return call[GetSiteDetailsResponse](ctx, c, "/site/"+strconv.Itoa(id)+"/details", nil)
+	return call[GetSiteDetailsResponse](ctx, c, makePath("/site/{siteId}/details", id), nil)
 }
 
 type GetSiteDetailsResponse struct {
@@ -135,8 +134,8 @@ type GetEnergyMeasurementsResponse struct {
 }
 
 type EnergyMeasurements struct {
-	TimeUnit   string   `json:"timeUnit"`
-	Unit       TimeUnit `json:"unit"`
+	TimeUnit   TimeUnit `json:"timeUnit"`
+	Unit       Unit     `json:"unit"`
 	MeasuredBy string   `json:"measuredBy"`
 	Values     []Value  `json:"values"`
 }
@@ -159,7 +158,7 @@ type GetEnergyForTimeframeResponse struct {
 }
 
 type SiteEnergyForTimeframe struct {
-	Unit                string         `json:"unit"`
+	Unit                Unit           `json:"unit"`
 	MeasuredBy          string         `json:"measuredBy"`
 	StartLifetimeEnergy LifetimeEnergy `json:"startLifetimeEnergy"`
 	EndLifetimeEnergy   LifetimeEnergy `json:"endLifetimeEnergy"`
@@ -168,7 +167,7 @@ type SiteEnergyForTimeframe struct {
 
 type LifetimeEnergy struct {
 	Date   string  `json:"date"`
-	Unit   string  `json:"unit"`
+	Unit   Unit    `json:"unit"`
 	Energy float64 `json:"energy"`
 }
 
@@ -190,10 +189,10 @@ type GetPowerMeasurementsResponse struct {
 }
 
 type PowerMeasurements struct {
-	TimeUnit   string  `json:"timeUnit"`
-	Unit       string  `json:"unit"`
-	MeasuredBy string  `json:"measuredBy"`
-	Values     []Value `json:"values"`
+	TimeUnit   TimeUnit `json:"timeUnit"`
+	Unit       Unit     `json:"unit"`
+	MeasuredBy string   `json:"measuredBy"`
+	Values     []Value  `json:"values"`
 }
 
 // GetPowerOverview returns the energy produced at the site for its entire lifetime, current year, month and day (in Wh) and current power (in W).
@@ -242,7 +241,7 @@ type GetPowerDetailsResponse struct {
 
 type PowerDetails struct {
 	TimeUnit TimeUnit        `json:"timeUnit"`
-	Unit     string          `json:"unit"`
+	Unit     Unit            `json:"unit"`
 	Meters   []MeterReadings `json:"meters"`
 }
 
@@ -278,7 +277,7 @@ type GetEnergyDetailsResponse struct {
 // EnergyDetails contains site energy measurements from meters such as consumption, export (feed-in), import (purchase), etc.
 type EnergyDetails struct {
 	TimeUnit TimeUnit        `json:"timeUnit"`
-	Unit     string          `json:"unit"`
+	Unit     Unit            `json:"unit"`
 	Meters   []MeterReadings `json:"meters"`
 }
 
@@ -295,7 +294,7 @@ type GetPowerFlowResponse struct {
 
 // PowerFlow contains current power flow between all elements of the site including PV array, storage (battery), loads (consumption) and grid.
 type PowerFlow struct {
-	Unit        string `json:"unit"`
+	Unit        Unit `json:"unit"`
 	Connections []struct {
 		From string `json:"from"`
 		To   string `json:"to"`
@@ -368,7 +367,7 @@ type GetEnvBenefitsResponse struct {
 
 type EnvBenefits struct {
 	GasEmissionSaved struct {
-		Units string  `json:"units"`
+		Units Unit    `json:"units"`
 		Co2   float64 `json:"co2"`
 		Nox   float64 `json:"nox"`
 		So2   float64 `json:"so2"`
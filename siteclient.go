@@ -0,0 +1,178 @@
+package solaredge
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SiteClient calls the API for a single site. It offers the same methods as Client, without the site ID.
+//
+// SiteClient caches the site's details and inventory the first time they are needed (see Details, Location and
+// Inventory). Keep the SiteClient for as long as the site is used, rather than creating a new one for each call.
+type SiteClient struct {
+	client    *Client
+	details   *SiteDetails
+	location  *time.Location
+	inventory *Inventory
+	id        int
+	lock      sync.Mutex
+}
+
+// Site returns a SiteClient for the site with the specified ID.
+func (c *Client) Site(id int) *SiteClient {
+	return &SiteClient{client: c, id: id}
+}
+
+// ID returns the site's ID.
+func (s *SiteClient) ID() int {
+	return s.id
+}
+
+// Details returns the site's details. The details are retrieved once and then cached. Use BypassCache to retrieve
+// them again.
+func (s *SiteClient) Details(ctx context.Context) (SiteDetails, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.details == nil || callOptionsFromContext(ctx).bypassCache {
+		resp, err := s.client.GetSiteDetails(ctx, s.id)
+		if err != nil {
+			return SiteDetails{}, err
+		}
+		s.details = &resp.Details
+		s.location = nil
+	}
+	return *s.details, nil
+}
+
+// Location returns the site's time zone, as reported in its details. Times reported by the API are the site's
+// local time, expressed in UTC: use Location to place them in the site's time zone.
+func (s *SiteClient) Location(ctx context.Context) (*time.Location, error) {
+	details, err := s.Details(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.location == nil {
+		if s.location, err = time.LoadLocation(details.Location.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+	return s.location, nil
+}
+
+// Inventory returns the site's inventory. The inventory is retrieved once and then cached. Use BypassCache to
+// retrieve it again.
+func (s *SiteClient) Inventory(ctx context.Context) (Inventory, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.inventory == nil || callOptionsFromContext(ctx).bypassCache {
+		resp, err := s.client.GetInventory(ctx, s.id)
+		if err != nil {
+			return Inventory{}, err
+		}
+		s.inventory = &resp.Inventory
+	}
+	return *s.inventory, nil
+}
+
+// Refresh clears the cached details and inventory. They are retrieved again the next time they are needed.
+func (s *SiteClient) Refresh() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.details = nil
+	s.location = nil
+	s.inventory = nil
+}
+
+// GetSiteDetails calls Client.GetSiteDetails for the site. Unlike Details, the response is not cached.
+func (s *SiteClient) GetSiteDetails(ctx context.Context) (GetSiteDetailsResponse, error) {
+	return s.client.GetSiteDetails(ctx, s.id)
+}
+
+// GetDataPeriod calls Client.GetDataPeriod for the site.
+func (s *SiteClient) GetDataPeriod(ctx context.Context) (GetDataPeriodResponse, error) {
+	return s.client.GetDataPeriod(ctx, s.id)
+}
+
+// GetEnergyMeasurements calls Client.GetEnergyMeasurements for the site.
+func (s *SiteClient) GetEnergyMeasurements(ctx context.Context, timeUnit TimeUnit, startDate time.Time, endDate time.Time) (GetEnergyMeasurementsResponse, error) {
+	return s.client.GetEnergyMeasurements(ctx, s.id, timeUnit, startDate, endDate)
+}
+
+// GetEnergyForTimeFrame calls Client.GetEnergyForTimeFrame for the site.
+func (s *SiteClient) GetEnergyForTimeFrame(ctx context.Context, startDate, endDate time.Time) (GetEnergyForTimeframeResponse, error) {
+	return s.client.GetEnergyForTimeFrame(ctx, s.id, startDate, endDate)
+}
+
+// GetPowerMeasurements calls Client.GetPowerMeasurements for the site.
+func (s *SiteClient) GetPowerMeasurements(ctx context.Context, startTime, endTime time.Time) (GetPowerMeasurementsResponse, error) {
+	return s.client.GetPowerMeasurements(ctx, s.id, startTime, endTime)
+}
+
+// GetPowerOverview calls Client.GetPowerOverview for the site.
+func (s *SiteClient) GetPowerOverview(ctx context.Context) (GetPowerOverviewResponse, error) {
+	return s.client.GetPowerOverview(ctx, s.id)
+}
+
+// GetPowerDetails calls Client.GetPowerDetails for the site.
+func (s *SiteClient) GetPowerDetails(ctx context.Context, start, end time.Time) (GetPowerDetailsResponse, error) {
+	return s.client.GetPowerDetails(ctx, s.id, start, end)
+}
+
+// GetEnergyDetails calls Client.GetEnergyDetails for the site.
+func (s *SiteClient) GetEnergyDetails(ctx context.Context, timeUnit TimeUnit, startTime, endTime time.Time) (GetEnergyDetailsResponse, error) {
+	return s.client.GetEnergyDetails(ctx, s.id, timeUnit, startTime, endTime)
+}
+
+// GetPowerFlow calls Client.GetPowerFlow for the site.
+func (s *SiteClient) GetPowerFlow(ctx context.Context) (GetPowerFlowResponse, error) {
+	return s.client.GetPowerFlow(ctx, s.id)
+}
+
+// GetStorageData calls Client.GetStorageData for the site.
+func (s *SiteClient) GetStorageData(ctx context.Context, startTime, endTime time.Time) (GetStorageDataResponse, error) {
+	return s.client.GetStorageData(ctx, s.id, startTime, endTime)
+}
+
+// GetEnvBenefits calls Client.GetEnvBenefits for the site.
+func (s *SiteClient) GetEnvBenefits(ctx context.Context) (GetEnvBenefitsResponse, error) {
+	return s.client.GetEnvBenefits(ctx, s.id)
+}
+
+// GetComponents calls Client.GetComponents for the site.
+func (s *SiteClient) GetComponents(ctx context.Context) (GetComponentsResponse, error) {
+	return s.client.GetComponents(ctx, s.id)
+}
+
+// GetInventory calls Client.GetInventory for the site. Unlike Inventory, the response is not cached.
+func (s *SiteClient) GetInventory(ctx context.Context) (GetInventoryResponse, error) {
+	return s.client.GetInventory(ctx, s.id)
+}
+
+// Inverter returns an InverterClient for the site's equipment with the specified serial number.
+func (s *SiteClient) Inverter(serialNr string) *InverterClient {
+	return &InverterClient{site: s, serialNr: serialNr}
+}
+
+// InverterClient calls the equipment API for a single inverter (or other piece of equipment) of a site.
+type InverterClient struct {
+	site     *SiteClient
+	serialNr string
+}
+
+// SerialNumber returns the equipment's serial number.
+func (i *InverterClient) SerialNumber() string {
+	return i.serialNr
+}
+
+// GetTechnicalData calls Client.GetInverterTechnicalData for the inverter.
+func (i *InverterClient) GetTechnicalData(ctx context.Context, startTime, endTime time.Time) (GetInverterTechnicalDataResponse, error) {
+	return i.site.client.GetInverterTechnicalData(ctx, i.site.id, i.serialNr, startTime, endTime)
+}
+
+// GetChangeLog calls Client.GetEquipmentChangeLog for the equipment.
+func (i *InverterClient) GetChangeLog(ctx context.Context) (GetEquipmentChangeLogResponse, error) {
+	return i.site.client.GetEquipmentChangeLog(ctx, i.site.id, i.serialNr)
+}
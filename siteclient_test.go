@@ -0,0 +1,86 @@
+package solaredge_test
+
+import (
+	"context"
+	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/solaredgetest"
+	"testing"
+	"time"
+)
+
+func TestSiteClient(t *testing.T) {
+	var details solaredge.SiteDetails
+	details.Id = 1
+	details.Name = "home"
+	details.Location.TimeZone = "Europe/Brussels"
+	s := solaredgetest.NewServer("KEY", solaredgetest.Site{
+		Details:   details,
+		Inventory: solaredge.Inventory{Inverters: []solaredge.InverterEquipment{{SN: "SN1"}}},
+	})
+	defer s.Close()
+	c := solaredge.Client{SiteKey: "KEY", HTTPClient: s.Client()}
+	site := c.Site(1)
+	ctx := context.Background()
+
+	for range 2 {
+		details, err := site.Details(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Name != "home" {
+			t.Errorf("got %q, want %q", details.Name, "home")
+		}
+		loc, err := site.Location(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loc.String() != "Europe/Brussels" {
+			t.Errorf("got %q, want %q", loc, "Europe/Brussels")
+		}
+		inventory, err := site.Inventory(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(inventory.Inverters) != 1 {
+			t.Errorf("got %d inverters, want 1", len(inventory.Inverters))
+		}
+	}
+	if got := s.Requests(1); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+
+	site.Refresh()
+	if _, err := site.Details(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Requests(1); got != 3 {
+		t.Errorf("got %d requests, want 3", got)
+	}
+
+	bypass := solaredge.WithCallOptions(ctx, solaredge.BypassCache())
+	if _, err := site.Details(bypass); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := site.Inventory(bypass); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Requests(1); got != 5 {
+		t.Errorf("got %d requests, want 5", got)
+	}
+
+	end := time.Now()
+	data, err := site.Inverter("SN1").GetTechnicalData(ctx, end.Add(-time.Hour), end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Data.Count == 0 {
+		t.Error("expected telemetries")
+	}
+	if _, err = site.Inverter("SN2").GetChangeLog(ctx); err == nil {
+		t.Error("expected an error for an unknown inverter")
+	}
+
+	if _, err = c.Site(2).Details(ctx); err == nil {
+		t.Error("expected an error for an unknown site")
+	}
+}
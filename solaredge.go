@@ -21,55 +21,227 @@ import (
 	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/clambin/solaredge/v2/internal/sitepath"
+	"log/slog"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
+	"time"
 )
 
+// Client calls the SolarEdge API. Use NewClient to create a Client with a validated configuration.
 type Client struct {
 	SiteKey    string
 	HTTPClient *http.Client
+	limiter    Limiter
+	logger     *slog.Logger
 	baseURL    string
+	userAgent  string
+	apiVersion string
+	timeout    time.Duration
+	retries    int
+	quota      *QuotaTracker
+	// maxResponseSize limits the size of a response. Zero means DefaultMaxResponseSize.
+	maxResponseSize int64
+	// normalizeUnits converts all values to their base unit when decoding a response. See WithNormalizeUnits.
+	normalizeUnits bool
 }
 
-const apiURL = "https://monitoringapi.solaredge.com"
+const (
+	apiURL     = "https://monitoringapi.solaredge.com"
+	apiVersion = "1.0.0"
+)
 
 func (c *Client) buildRequest(ctx context.Context, endpoint string, args url.Values) (*http.Request, error) {
 	if args == nil {
 		args = make(url.Values)
 	}
 	args.Add("api_key", c.SiteKey)
-	args.Add("version", "1.0.0")
+	args.Add("version", cmp.Or(c.apiVersion, apiVersion))
 
 	fullURL := cmp.Or(c.baseURL, apiURL) + endpoint + "?" + args.Encode()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
 	if err == nil {
 		req.Header.Set("Accept", "application/json")
+		if c.userAgent != "" {
+			req.Header.Set("User-Agent", c.userAgent)
+		}
 	}
 	return req, err
 }
+
 func call[T any](ctx context.Context, c *Client, path string, args url.Values) (T, error) {
 	var response T
-	req, err := c.buildRequest(ctx, path, args)
+	resp, cancel, err := c.do(ctx, path, args)
 	if err != nil {
 		return response, err
 	}
+	defer cancel()
+	defer func() { _ = resp.Body.Close() }()
+
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	if err == nil && c.normalizeUnits {
+		if n, ok := any(&response).(unitNormalizer); ok {
+			n.normalizeUnits()
+		}
+	}
+	return response, err
+}
+
+// do sends the request and returns the response, if the server returned it with status OK. The caller must close
+// the response's body and call cancel once the body has been read.
+//
+// do applies the CallOptions held by ctx. Failed calls are retried if the error may be transient.
+func (c *Client) do(ctx context.Context, path string, args url.Values) (*http.Response, context.CancelFunc, error) {
+	opts := callOptionsFromContext(ctx)
+	timeout := c.timeout
+	if opts.timeout != nil {
+		timeout = *opts.timeout
+	}
+	retries := c.retries
+	if opts.retries != nil {
+		retries = *opts.retries
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	var resp *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		resp, err = c.send(ctx, path, args)
+		if attempt >= retries || !retryable(ctx, resp, err) {
+			break
+		}
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		c.log(ctx, "retrying api call", "path", path, "attempt", attempt+1)
+		if err = sleep(ctx, retryBackoff<<attempt); err != nil {
+			break
+		}
+	}
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	resp.Body = limitBody(resp.Body, cmp.Or(c.maxResponseSize, DefaultMaxResponseSize))
+	if resp.StatusCode != http.StatusOK {
+		err = newResponseError(resp)
+	} else {
+		err = checkJSON(resp)
+	}
+	if err != nil {
+		_ = resp.Body.Close()
+		cancel()
+		return nil, nil, err
+	}
+	return resp, cancel, nil
+}
+
+// send waits for the limiter and sends the request once.
+func (c *Client) send(ctx context.Context, path string, args url.Values) (*http.Response, error) {
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	req, err := c.buildRequest(ctx, path, args)
+	if err != nil {
+		return nil, err
+	}
 	httpClient := cmp.Or(c.HTTPClient, http.DefaultClient)
+	start := time.Now()
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return response, err
+		err = redactKey(err)
+		c.log(ctx, "api call failed", "path", path, "err", err)
+		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	c.log(ctx, "api call", "path", path, "status", resp.StatusCode, "duration", time.Since(start))
+	if c.quota != nil {
+		if err = c.quota.record(c.SiteKey, sitepath.SiteID(path)); err != nil {
+			c.log(ctx, "failed to record quota", "err", err)
+		}
+	}
+	return resp, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return response, newResponseError(resp)
+// redactKey removes the API key from the request URL reported by an error of the HTTP client, so the error can be
+// logged safely.
+func redactKey(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if u, parseErr := url.Parse(urlErr.URL); parseErr == nil {
+			q := u.Query()
+			if q.Has("api_key") {
+				q.Set("api_key", "REDACTED")
+				u.RawQuery = q.Encode()
+			}
+			urlErr.URL = u.String()
+		}
+	}
+	return err
+}
+
+// retryBackoff is the time to wait before the first retry. The wait doubles with each retry.
+var retryBackoff = time.Second
+
+// retryable returns true if a call that failed with the response or error may succeed when retried: the request
+// couldn't be sent or the server failed. A call isn't retried if the server returned status 429, as this means the
+// daily quota is exhausted, nor if the Limiter failed or the context is done.
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		var urlErr *url.Error
+		return errors.As(err, &urlErr) && ctx.Err() == nil &&
+			!errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) log(ctx context.Context, msg string, args ...any) {
+	if c.logger != nil {
+		c.logger.DebugContext(ctx, msg, args...)
 	}
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	return response, err
 }
 
-func makePath(path string, siteId int) string {
-	return strings.ReplaceAll(path, "{siteId}", strconv.Itoa(siteId))
+// makePath returns the path for a template, replacing each placeholder (e.g. {siteId}) by the next value.
+// Values are escaped, so they can't alter the structure of the path. makePath panics if the number of values
+// doesn't match the number of placeholders.
+func makePath(template string, values ...any) string {
+	var path strings.Builder
+	for _, value := range values {
+		before, placeholder, ok := strings.Cut(template, "{")
+		if !ok {
+			panic("makePath: too many values for " + template)
+		}
+		_, after, ok := strings.Cut(placeholder, "}")
+		if !ok {
+			panic("makePath: unterminated placeholder in " + template)
+		}
+		path.WriteString(before)
+		path.WriteString(url.PathEscape(fmt.Sprint(value)))
+		template = after
+	}
+	if strings.Contains(template, "{") {
+		panic("makePath: not enough values for " + template)
+	}
+	path.WriteString(template)
+	return path.String()
 }
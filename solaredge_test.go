@@ -29,6 +29,50 @@ func expect(t *testing.T, response any, path string, err error) {
 	}
 }
 
+func TestMakePath(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		values   []any
+		want     string
+	}{
+		{name: "site", template: "/site/{siteId}/details", values: []any{1}, want: "/site/1/details"},
+		{name: "serial number", template: "/equipment/{siteId}/{serialNumber}/data", values: []any{1, "7F104920-8E"}, want: "/equipment/1/7F104920-8E/data"},
+		{name: "slash", template: "/equipment/{siteId}/{serialNumber}/data", values: []any{1, "SN1/../list"}, want: "/equipment/1/SN1%2F..%2Flist/data"},
+		{name: "query", template: "/equipment/{siteId}/{serialNumber}/data", values: []any{1, "SN1?api_key=x"}, want: "/equipment/1/SN1%3Fapi_key=x/data"},
+		{name: "space", template: "/equipment/{siteId}/{serialNumber}/data", values: []any{1, "SN 1"}, want: "/equipment/1/SN%201/data"},
+		{name: "fragment", template: "/equipment/{siteId}/{serialNumber}/data", values: []any{1, "SN1#x"}, want: "/equipment/1/SN1%23x/data"},
+		{name: "no placeholders", template: "/sites/list", want: "/sites/list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := makePath(tt.template, tt.values...); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakePath_Panics(t *testing.T) {
+	for _, tt := range []struct {
+		template string
+		values   []any
+	}{
+		{template: "/site/{siteId}/details"},
+		{template: "/sites/list", values: []any{1}},
+		{template: "/site/{siteId/details", values: []any{1}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", tt.template)
+				}
+			}()
+			makePath(tt.template, tt.values...)
+		}()
+	}
+}
+
 func NewTestServer() *httptest.Server {
 	responses := make(testutils.Responses)
 	for path, response := range testResponses {
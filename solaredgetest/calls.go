@@ -0,0 +1,24 @@
+package solaredgetest
+
+import "errors"
+
+//go:generate go run ./internal/mockgen -source ../api.go -o mock.go
+
+// ErrNotMocked is returned by a Mock method whose function has not been set.
+var ErrNotMocked = errors.New("method not mocked")
+
+func (m *Mock) called(method string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.calls == nil {
+		m.calls = make(map[string]int)
+	}
+	m.calls[method]++
+}
+
+// Calls returns the number of times a method (e.g. "GetSites") has been called.
+func (m *Mock) Calls(method string) int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.calls[method]
+}
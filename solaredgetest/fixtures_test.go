@@ -0,0 +1,91 @@
+package solaredgetest_test
+
+import (
+	"context"
+	"errors"
+	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/solaredgetest"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder_Replayer(t *testing.T) {
+	s, _, now := newServer(t)
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	recorder := solaredge.Client{SiteKey: apiKey, HTTPClient: &http.Client{Transport: &solaredgetest.Recorder{Dir: dir, Next: s.Client().Transport}}}
+	recorded, err := recorder.GetPowerMeasurements(ctx, 1, now.Add(-2*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = recorder.GetSiteDetails(ctx, 2); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("got %d fixtures, want 2", len(files))
+	}
+	for _, file := range files {
+		content, _ := os.ReadFile(file)
+		if strings.Contains(string(content), apiKey) {
+			t.Errorf("%s: api_key not scrubbed", file)
+		}
+	}
+
+	s.Close()
+	replayer := solaredge.Client{SiteKey: "other key", HTTPClient: &http.Client{Transport: &solaredgetest.Replayer{Dir: dir}}}
+	replayed, err := replayer.GetPowerMeasurements(ctx, 1, now.Add(-2*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("got %v, want %v", replayed, recorded)
+	}
+	if _, err = replayer.GetSiteDetails(ctx, 2); err == nil || !strings.Contains(err.Error(), "Invalid site ID") {
+		t.Errorf("expected the recorded error, got %v", err)
+	}
+	if _, err = replayer.GetPowerMeasurements(ctx, 1, now.Add(-time.Hour), now); !errors.Is(err, solaredgetest.ErrNoFixture) {
+		t.Errorf("expected ErrNoFixture, got %v", err)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestRecorder_Redact(t *testing.T) {
+	// a server that echoes the request's URL in the response
+	echo := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		header := make(http.Header)
+		header.Set("Content-Type", "text/html; url="+req.URL.String())
+		return &http.Response{
+			StatusCode: http.StatusForbidden,
+			Header:     header,
+			Body:       io.NopCloser(strings.NewReader("<html>Invalid token: " + req.URL.String() + "</html>")),
+			Request:    req,
+		}, nil
+	})
+	dir := t.TempDir()
+	c := solaredge.Client{SiteKey: apiKey, HTTPClient: &http.Client{Transport: &solaredgetest.Recorder{Dir: dir, Next: echo}}}
+	_, _ = c.GetSiteDetails(context.Background(), 1)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("got %d fixtures, want 1", len(files))
+	}
+	content, _ := os.ReadFile(files[0])
+	if strings.Contains(string(content), apiKey) {
+		t.Errorf("api_key not scrubbed: %s", content)
+	}
+	if !strings.Contains(string(content), "REDACTED") {
+		t.Errorf("api_key not redacted: %s", content)
+	}
+}
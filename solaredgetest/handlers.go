@@ -0,0 +1,359 @@
+package solaredgetest
+
+import (
+	"github.com/clambin/solaredge/v2"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const timeFormat = "2006-01-02 15:04:05"
+
+// range limits imposed by the API
+var (
+	oneWeek  = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	oneMonth = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	oneYear  = func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }
+)
+
+func siteDetails(s *site, _ *http.Request, _ time.Time) (any, error) {
+	return solaredge.GetSiteDetailsResponse{Details: s.Details}, nil
+}
+
+func dataPeriod(s *site, _ *http.Request, now time.Time) (any, error) {
+	return solaredge.GetDataPeriodResponse{DataPeriod: solaredge.DataPeriod{
+		StartDate: solaredge.Date(s.installationDate(now)),
+		EndDate:   solaredge.Date(startOf(now.In(s.location), solaredge.TimeUnitDay)),
+	}}, nil
+}
+
+func energy(s *site, r *http.Request, now time.Time) (any, error) {
+	unit, start, end, err := s.energyParams(r, "startDate", "endDate", time.DateOnly)
+	if err != nil {
+		return nil, err
+	}
+	var response solaredge.GetEnergyMeasurementsResponse
+	response.Energy.TimeUnit = unit
+	response.Energy.Unit = solaredge.UnitWh
+	response.Energy.MeasuredBy = "INVERTER"
+	response.Energy.Values = make([]solaredge.Value, 0)
+	for _, p := range s.periods(unit, start, end, now) {
+		response.Energy.Values = append(response.Energy.Values, value(p.start, p.values[meterProduction]))
+	}
+	return response, nil
+}
+
+func energyDetails(s *site, r *http.Request, now time.Time) (any, error) {
+	unit, start, end, err := s.energyParams(r, "startTime", "endTime", timeFormat)
+	if err != nil {
+		return nil, err
+	}
+	var response solaredge.GetEnergyDetailsResponse
+	response.EnergyDetails.TimeUnit = unit
+	response.EnergyDetails.Unit = solaredge.UnitWh
+	response.EnergyDetails.Meters = meterReadings(s.periods(unit, start, end, now))
+	return response, nil
+}
+
+// energyParams parses the parameters of the energy endpoints. The time range is limited to one month for quarterly
+// and hourly values and to one year for daily values.
+func (s *site) energyParams(r *http.Request, startParam, endParam, layout string) (solaredge.TimeUnit, time.Time, time.Time, error) {
+	unit := solaredge.TimeUnit(r.URL.Query().Get("timeUnit"))
+	if unit == "" {
+		unit = solaredge.TimeUnitDay
+	}
+	limit := map[solaredge.TimeUnit]func(time.Time) time.Time{
+		solaredge.TimeUnitQuarter: oneMonth,
+		solaredge.TimeUnitHour:    oneMonth,
+		solaredge.TimeUnitDay:     oneYear,
+		solaredge.TimeUnitWeek:    nil,
+		solaredge.TimeUnitMonth:   nil,
+		solaredge.TimeUnitYear:    nil,
+	}
+	l, ok := limit[unit]
+	if !ok {
+		return "", time.Time{}, time.Time{}, badRequest("Invalid value for parameter 'timeUnit'")
+	}
+	start, end, err := s.timeRange(r, startParam, endParam, layout, l)
+	return unit, start, end, err
+}
+
+func timeFrameEnergy(s *site, r *http.Request, now time.Time) (any, error) {
+	start, end, err := s.timeRange(r, "startDate", "endDate", time.DateOnly, oneYear)
+	if err != nil {
+		return nil, err
+	}
+	installed := s.installationDate(now)
+	atStart := s.energy(installed, start, now)[meterProduction]
+	energy := s.energy(start, end.Add(time.Nanosecond), now)[meterProduction]
+
+	var response solaredge.GetEnergyForTimeframeResponse
+	response.TimeFrameEnergy.Unit = solaredge.UnitWh
+	response.TimeFrameEnergy.MeasuredBy = "INVERTER"
+	response.TimeFrameEnergy.Energy = energy
+	response.TimeFrameEnergy.StartLifetimeEnergy = solaredge.LifetimeEnergy{Date: start.Format(time.DateOnly), Unit: solaredge.UnitWh, Energy: atStart}
+	response.TimeFrameEnergy.EndLifetimeEnergy = solaredge.LifetimeEnergy{Date: end.Format(time.DateOnly), Unit: solaredge.UnitWh, Energy: atStart + energy}
+	return response, nil
+}
+
+func power(s *site, r *http.Request, now time.Time) (any, error) {
+	start, end, err := s.timeRange(r, "startTime", "endTime", timeFormat, oneMonth)
+	if err != nil {
+		return nil, err
+	}
+	var response solaredge.GetPowerMeasurementsResponse
+	response.Power.TimeUnit = solaredge.TimeUnitQuarter
+	response.Power.Unit = solaredge.UnitW
+	response.Power.MeasuredBy = "INVERTER"
+	response.Power.Values = make([]solaredge.Value, 0)
+	for _, q := range s.quarters(start, end, now) {
+		response.Power.Values = append(response.Power.Values, value(q, s.meters(q)[meterProduction]))
+	}
+	return response, nil
+}
+
+func powerDetails(s *site, r *http.Request, now time.Time) (any, error) {
+	start, end, err := s.timeRange(r, "startTime", "endTime", timeFormat, oneMonth)
+	if err != nil {
+		return nil, err
+	}
+	var periods []period
+	for _, q := range s.quarters(start, end, now) {
+		periods = append(periods, period{start: q, values: s.meters(q)})
+	}
+	var response solaredge.GetPowerDetailsResponse
+	response.PowerDetails.TimeUnit = solaredge.TimeUnitQuarter
+	response.PowerDetails.Unit = solaredge.UnitW
+	response.PowerDetails.Meters = meterReadings(periods)
+	return response, nil
+}
+
+func meterReadings(periods []period) []solaredge.MeterReadings {
+	readings := make([]solaredge.MeterReadings, 0, len(meterTypes))
+	for _, meter := range meterTypes {
+		values := make([]solaredge.Value, 0, len(periods))
+		for _, p := range periods {
+			values = append(values, value(p.start, p.values[meter]))
+		}
+		readings = append(readings, solaredge.MeterReadings{Type: meter, Values: values})
+	}
+	return readings
+}
+
+func overview(s *site, _ *http.Request, now time.Time) (any, error) {
+	local := now.In(s.location)
+	energy := func(unit solaredge.TimeUnit) solaredge.EnergyOverview {
+		return solaredge.EnergyOverview{Energy: s.energy(startOf(local, unit), now, now)[meterProduction]}
+	}
+	return solaredge.GetPowerOverviewResponse{Overview: solaredge.PowerOverview{
+		LastUpdateTime: solaredge.Time(now.In(s.location)),
+		LifeTimeData:   solaredge.EnergyOverview{Energy: s.energy(s.installationDate(now), now, now)[meterProduction]},
+		LastYearData:   energy(solaredge.TimeUnitYear),
+		LastMonthData:  energy(solaredge.TimeUnitMonth),
+		LastDayData:    energy(solaredge.TimeUnitDay),
+		CurrentPower:   solaredge.CurrentPower{Power: s.production(now)},
+	}}, nil
+}
+
+func powerFlow(s *site, _ *http.Request, now time.Time) (any, error) {
+	production, consumption := s.production(now)/1000, s.consumption(now)/1000
+	var flow solaredge.PowerFlow
+	flow.Unit = solaredge.UnitKW
+	flow.PV = solaredge.PowerFlowReading{Status: status(production), CurrentPower: production}
+	flow.Load = solaredge.PowerFlowReading{Status: status(consumption), CurrentPower: consumption}
+	flow.Grid = solaredge.PowerFlowReading{Status: status(production - consumption), CurrentPower: max(production-consumption, consumption-production)}
+	connect := func(from, to string) {
+		flow.Connections = append(flow.Connections, struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+		}{From: from, To: to})
+	}
+	if production > 0 {
+		connect("PV", "Load")
+	}
+	if production > consumption {
+		connect("LOAD", "Grid")
+	} else if consumption > production {
+		connect("GRID", "Load")
+	}
+	if len(s.Inventory.Batteries) > 0 {
+		flow.Storage.Status = "Idle"
+		flow.Storage.ChargeLevel = 50
+	}
+	return solaredge.GetPowerFlowResponse{CurrentPowerFlow: flow}, nil
+}
+
+func status(power float64) string {
+	if power == 0 {
+		return "Idle"
+	}
+	return "Active"
+}
+
+func storageData(s *site, r *http.Request, now time.Time) (any, error) {
+	start, end, err := s.timeRange(r, "startTime", "endTime", timeFormat, oneWeek)
+	if err != nil {
+		return nil, err
+	}
+	var response solaredge.GetStorageDataResponse
+	response.StorageData.Batteries = make([]solaredge.Battery, 0, len(s.Inventory.Batteries))
+	for _, b := range s.Inventory.Batteries {
+		battery := solaredge.Battery{SerialNumber: b.SN, ModelNumber: b.Model, Nameplate: int(b.NameplateCapacity)}
+		var charged, discharged float64
+		for _, q := range s.quarters(start, end, now) {
+			m := s.meters(q)
+			// the battery absorbs surplus production and covers part of the evening consumption
+			power := min(m[meterFeedIn], b.NameplateCapacity/4) - min(m[meterPurchased], b.NameplateCapacity/4)
+			if power > 0 {
+				charged += power * quarter.Hours()
+			} else {
+				discharged -= power * quarter.Hours()
+			}
+			battery.Telemetries = append(battery.Telemetries, solaredge.BatteryTelemetry{
+				TimeStamp:                solaredge.Time(q),
+				Power:                    power,
+				BatteryState:             3,
+				LifeTimeEnergyCharged:    charged,
+				LifeTimeEnergyDischarged: discharged,
+				FullPackEnergyAvailable:  b.NameplateCapacity,
+				InternalTemp:             20,
+			})
+		}
+		battery.TelemetryCount = len(battery.Telemetries)
+		response.StorageData.Batteries = append(response.StorageData.Batteries, battery)
+	}
+	response.StorageData.BatteryCount = len(response.StorageData.Batteries)
+	return response, nil
+}
+
+func envBenefits(s *site, _ *http.Request, now time.Time) (any, error) {
+	kWh := s.energy(s.installationDate(now), now, now)[meterProduction] / 1000
+	var response solaredge.GetEnvBenefitsResponse
+	response.EnvBenefits.GasEmissionSaved.Units = solaredge.UnitKG
+	response.EnvBenefits.GasEmissionSaved.Co2 = 0.5 * kWh
+	response.EnvBenefits.GasEmissionSaved.So2 = 0.0004 * kWh
+	response.EnvBenefits.GasEmissionSaved.Nox = 0.0003 * kWh
+	response.EnvBenefits.TreesPlanted = 0.5 * kWh / 20
+	response.EnvBenefits.LightBulbs = kWh / 0.6
+	return response, nil
+}
+
+func components(s *site, _ *http.Request, _ time.Time) (any, error) {
+	var response solaredge.GetComponentsResponse
+	response.Reporters.List = make([]solaredge.Inverter, 0, len(s.Inventory.Inverters))
+	for _, inverter := range s.Inventory.Inverters {
+		response.Reporters.List = append(response.Reporters.List, solaredge.Inverter{
+			Manufacturer: inverter.Manufacturer,
+			Model:        inverter.Model,
+			Name:         inverter.Name,
+			SerialNumber: inverter.SN,
+		})
+	}
+	response.Reporters.Count = len(response.Reporters.List)
+	return response, nil
+}
+
+func inventory(s *site, _ *http.Request, _ time.Time) (any, error) {
+	return solaredge.GetInventoryResponse{Inventory: s.Inventory}, nil
+}
+
+func inverterData(s *site, r *http.Request, now time.Time) (any, error) {
+	if !s.hasInverter(r.PathValue("serial")) {
+		return nil, forbidden("Invalid serial number %s", r.PathValue("serial"))
+	}
+	start, end, err := s.timeRange(r, "startTime", "endTime", timeFormat, oneWeek)
+	if err != nil {
+		return nil, err
+	}
+	share := 1 / float64(len(s.Inventory.Inverters))
+	var response solaredge.GetInverterTechnicalDataResponse
+	response.Data.Telemetries = make([]solaredge.InverterTelemetry, 0)
+	if slices.Contains(s.SilentInverters, r.PathValue("serial")) {
+		return response, nil
+	}
+	var total float64
+	for _, q := range s.quarters(start, end, now) {
+		power := s.production(q) * share
+		total += power * quarter.Hours()
+		t := solaredge.InverterTelemetry{
+			Time:             solaredge.Time(q),
+			InverterMode:     "SLEEPING",
+			TotalActivePower: power,
+			TotalEnergy:      total,
+			Temperature:      20 + 25*power/(s.peakPower*1000*share),
+			L1Data: solaredge.InverterTelemetryL1Data{
+				AcFrequency: 50,
+				AcVoltage:   230,
+				ActivePower: power,
+				CosPhi:      1,
+			},
+		}
+		if power > 0 {
+			t.InverterMode = "MPPT"
+			t.OperationMode = 0
+			t.DcVoltage = 380
+			t.PowerLimit = 100
+			t.GroundFaultResistance = 10000
+			t.L1Data.AcCurrent = power / t.L1Data.AcVoltage
+			t.L1Data.ApparentPower = power
+		}
+		response.Data.Telemetries = append(response.Data.Telemetries, t)
+	}
+	response.Data.Count = len(response.Data.Telemetries)
+	return response, nil
+}
+
+func changeLog(s *site, r *http.Request, _ time.Time) (any, error) {
+	if !s.hasInverter(r.PathValue("serial")) {
+		return nil, forbidden("Invalid serial number %s", r.PathValue("serial"))
+	}
+	var response solaredge.GetEquipmentChangeLogResponse
+	response.ChangeLog.List = make([]solaredge.EquipmentChangeLog, 0)
+	return response, nil
+}
+
+func (s *site) hasInverter(serialNr string) bool {
+	return slices.ContainsFunc(s.Inventory.Inverters, func(inverter solaredge.InverterEquipment) bool {
+		return inverter.SN == serialNr
+	})
+}
+
+// timeRange parses the start and end of the requested time range, in the site's time zone. If limit is not nil,
+// the range may not exceed limit(start). Dates are inclusive: the range ends at the end of the end date.
+func (s *site) timeRange(r *http.Request, startParam, endParam, layout string, limit func(time.Time) time.Time) (time.Time, time.Time, error) {
+	start, err := s.parseTime(r, startParam, layout)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := s.parseTime(r, endParam, layout)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, forbidden("Start date must be before end date")
+	}
+	if limit != nil && end.After(limit(start)) {
+		return time.Time{}, time.Time{}, forbidden("The requested time frame exceeds the allowed limit")
+	}
+	if layout == time.DateOnly {
+		end = end.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return start, end, nil
+}
+
+func (s *site) parseTime(r *http.Request, param, layout string) (time.Time, error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return time.Time{}, badRequest("Required parameter '%s' is not present", param)
+	}
+	t, err := time.ParseInLocation(layout, value, s.location)
+	if err != nil {
+		return time.Time{}, badRequest("Invalid value for parameter '%s'", param)
+	}
+	return t, nil
+}
+
+// value returns a Value at time t. t must be in the site's time zone, so the Value reports the site's local time.
+func value(t time.Time, v float64) solaredge.Value {
+	return solaredge.Value{Date: solaredge.Time(t), Value: v}
+}
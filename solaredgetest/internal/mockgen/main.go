@@ -0,0 +1,149 @@
+// Command mockgen generates solaredgetest.Mock from the interfaces declared in the solaredge package's api.go.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	source := flag.String("source", "../api.go", "file declaring the interfaces")
+	output := flag.String("o", "mock.go", "output file")
+	flag.Parse()
+
+	methods, err := parseMethods(*source, "SiteDataAPI", "EquipmentAPI", "VersionAPI")
+	if err != nil {
+		log.Fatal(err)
+	}
+	code, err := generate(methods)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(*output, code, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type method struct {
+	name    string
+	params  []param
+	results []string
+}
+
+type param struct {
+	name string
+	typ  string
+}
+
+// parseMethods returns the methods of the interfaces, in order of declaration.
+func parseMethods(source string, interfaces ...string) ([]method, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), source, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	var methods []method
+	for _, name := range interfaces {
+		obj := f.Scope.Lookup(name)
+		if obj == nil {
+			return nil, fmt.Errorf("%s: interface %s not found", source, name)
+		}
+		iface, ok := obj.Decl.(*ast.TypeSpec).Type.(*ast.InterfaceType)
+		if !ok {
+			return nil, fmt.Errorf("%s: %s is not an interface", source, name)
+		}
+		for _, field := range iface.Methods.List {
+			fn := field.Type.(*ast.FuncType)
+			m := method{name: field.Names[0].Name}
+			for _, p := range fn.Params.List {
+				for _, n := range p.Names {
+					m.params = append(m.params, param{name: n.Name, typ: typeName(p.Type)})
+				}
+			}
+			for _, r := range fn.Results.List {
+				m.results = append(m.results, typeName(r.Type))
+			}
+			methods = append(methods, m)
+		}
+	}
+	return methods, nil
+}
+
+// typeName returns the name of the type, qualifying the solaredge package's types.
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if ast.IsExported(t.Name) {
+			return "solaredge." + t.Name
+		}
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.X.(*ast.Ident).Name + "." + t.Sel.Name
+	default:
+		panic(fmt.Sprintf("unsupported type %T", expr))
+	}
+}
+
+func generate(methods []method) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(`// Code generated by mockgen. DO NOT EDIT.
+
+package solaredgetest
+
+import (
+	"context"
+	"github.com/clambin/solaredge/v2"
+	"sync"
+	"time"
+)
+
+var _ solaredge.API = &Mock{}
+
+// Mock is an in-memory implementation of solaredge.API. Each method calls the function in the corresponding field
+// (e.g. GetSites calls GetSitesFunc). If the function is not set, the method returns ErrNotMocked.
+//
+// Mock records the number of calls of each method. See Calls.
+type Mock struct {
+`)
+	for _, m := range methods {
+		fmt.Fprintf(&b, "\t%sFunc func(%s) (%s)\n", m.name, m.signature(), strings.Join(m.results, ", "))
+	}
+	b.WriteString(`
+	calls map[string]int
+	lock  sync.Mutex
+}
+`)
+	for _, m := range methods {
+		args := make([]string, len(m.params))
+		for i, p := range m.params {
+			args[i] = p.name
+		}
+		fmt.Fprintf(&b, `
+// %[1]s calls %[1]sFunc.
+func (m *Mock) %[1]s(%[2]s) (%[3]s) {
+	m.called(%[1]q)
+	if m.%[1]sFunc == nil {
+		var response %[4]s
+		return response, ErrNotMocked
+	}
+	return m.%[1]sFunc(%[5]s)
+}
+`, m.name, m.signature(), strings.Join(m.results, ", "), m.results[0], strings.Join(args, ", "))
+	}
+	return format.Source(b.Bytes())
+}
+
+func (m method) signature() string {
+	params := make([]string, len(m.params))
+	for i, p := range m.params {
+		params[i] = p.name + " " + p.typ
+	}
+	return strings.Join(params, ", ")
+}
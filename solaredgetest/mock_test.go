@@ -0,0 +1,60 @@
+package solaredgetest_test
+
+import (
+	"context"
+	"errors"
+	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/solaredgetest"
+	"testing"
+	"time"
+)
+
+func TestMock(t *testing.T) {
+	m := solaredgetest.Mock{
+		GetSiteDetailsFunc: func(_ context.Context, id int) (solaredge.GetSiteDetailsResponse, error) {
+			return solaredge.GetSiteDetailsResponse{Details: solaredge.SiteDetails{Id: id, Name: "home"}}, nil
+		},
+	}
+	var api solaredge.SiteDataAPI = &m
+	ctx := context.Background()
+
+	for range 2 {
+		details, err := api.GetSiteDetails(ctx, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if details.Details.Id != 1 || details.Details.Name != "home" {
+			t.Errorf("unexpected details: %v", details.Details)
+		}
+	}
+	if _, err := api.GetPowerOverview(ctx, 1); !errors.Is(err, solaredgetest.ErrNotMocked) {
+		t.Errorf("expected ErrNotMocked, got %v", err)
+	}
+
+	for method, want := range map[string]int{"GetSiteDetails": 2, "GetPowerOverview": 1, "GetSites": 0} {
+		if got := m.Calls(method); got != want {
+			t.Errorf("%s: got %d calls, want %d", method, got, want)
+		}
+	}
+}
+
+func TestMock_Watcher(t *testing.T) {
+	m := solaredgetest.Mock{
+		GetPowerOverviewFunc: func(_ context.Context, _ int) (solaredge.GetPowerOverviewResponse, error) {
+			var response solaredge.GetPowerOverviewResponse
+			response.Overview.CurrentPower.Power = 200
+			return response, nil
+		},
+	}
+	w := solaredge.Watcher{Client: &m, Sites: []int{1}, DailyQuota: 1e9, MinInterval: time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	e, ok := <-w.Events(ctx)
+	if event, isOverview := e.(solaredge.OverviewEvent); !ok || !isOverview || event.Overview.CurrentPower.Power != 200 {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if m.Calls("GetPowerOverview") == 0 {
+		t.Error("expected GetPowerOverview to be called")
+	}
+}
@@ -0,0 +1,159 @@
+package solaredgetest
+
+import (
+	"cmp"
+	"github.com/clambin/solaredge/v2"
+	"math"
+	"slices"
+	"time"
+)
+
+const (
+	quarter          = 15 * time.Minute
+	defaultPeakPower = 5.0
+)
+
+// meter types, as reported by GetPowerDetails and GetEnergyDetails
+const (
+	meterProduction      = "Production"
+	meterConsumption     = "Consumption"
+	meterSelfConsumption = "SelfConsumption"
+	meterFeedIn          = "FeedIn"
+	meterPurchased       = "Purchased"
+)
+
+var meterTypes = []string{meterProduction, meterConsumption, meterSelfConsumption, meterFeedIn, meterPurchased}
+
+type site struct {
+	Site
+	location  *time.Location
+	peakPower float64
+}
+
+func newSite(s Site) *site {
+	loc, err := time.LoadLocation(s.Details.Location.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return &site{
+		Site:      s,
+		location:  loc,
+		peakPower: cmp.Or(s.Details.PeakPower, defaultPeakPower),
+	}
+}
+
+func (s *site) installationDate(now time.Time) time.Time {
+	if d := time.Time(s.Details.InstallationDate); !d.IsZero() {
+		return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, s.location)
+	}
+	return startOf(now.In(s.location).AddDate(-1, 0, 0), solaredge.TimeUnitDay)
+}
+
+// production returns the modelled production of the site at time t, in W.
+func (s *site) production(t time.Time) float64 {
+	t = t.In(s.location)
+	h := hourOfDay(t)
+	if h <= 6 || h >= 20 {
+		return 0
+	}
+	season := 0.6 + 0.4*math.Cos(2*math.Pi*float64(t.YearDay()-172)/365)
+	return s.peakPower * 1000 * 0.8 * season * math.Sin(math.Pi*(h-6)/14)
+}
+
+// consumption returns the modelled consumption of the site at time t, in W.
+func (s *site) consumption(t time.Time) float64 {
+	h := hourOfDay(t.In(s.location))
+	return 300 + 1200*math.Exp(-(h-8)*(h-8)/2) + 2000*math.Exp(-(h-19)*(h-19)/4)
+}
+
+func hourOfDay(t time.Time) float64 {
+	return float64(t.Hour()) + float64(t.Minute())/60 + float64(t.Second())/3600
+}
+
+// meters returns the average power of each meter during the quarter of an hour starting at t, in W.
+func (s *site) meters(t time.Time) map[string]float64 {
+	mid := t.Add(quarter / 2)
+	production, consumption := s.production(mid), s.consumption(mid)
+	self := min(production, consumption)
+	return map[string]float64{
+		meterProduction:      production,
+		meterConsumption:     consumption,
+		meterSelfConsumption: self,
+		meterFeedIn:          production - self,
+		meterPurchased:       consumption - self,
+	}
+}
+
+// quarters returns the start of each quarter of an hour in [start, end], up to now.
+func (s *site) quarters(start, end, now time.Time) []time.Time {
+	start = start.In(s.location)
+	t := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), start.Minute()/15*15, 0, 0, s.location)
+	var quarters []time.Time
+	for ; !t.After(end) && t.Before(now); t = t.Add(quarter) {
+		quarters = append(quarters, t)
+	}
+	return quarters
+}
+
+// energy returns the energy of each meter in [start, end), up to now, in Wh.
+func (s *site) energy(start, end, now time.Time) map[string]float64 {
+	energy := make(map[string]float64, len(meterTypes))
+	for _, q := range s.quarters(start, end.Add(-time.Nanosecond), now) {
+		for meter, value := range s.meters(q) {
+			energy[meter] += value * quarter.Hours()
+		}
+	}
+	return energy
+}
+
+type period struct {
+	start  time.Time
+	values map[string]float64
+}
+
+// periods returns the energy of each meter for the periods of the time unit between start and end, up to now.
+func (s *site) periods(unit solaredge.TimeUnit, start, end, now time.Time) []period {
+	var periods []period
+	for p := startOf(start.In(s.location), unit); !p.After(end) && p.Before(now); p = next(p, unit) {
+		periods = append(periods, period{start: p, values: s.energy(p, next(p, unit), now)})
+	}
+	return periods
+}
+
+func startOf(t time.Time, unit solaredge.TimeUnit) time.Time {
+	switch unit {
+	case solaredge.TimeUnitQuarter:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()/15*15, 0, 0, t.Location())
+	case solaredge.TimeUnitHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case solaredge.TimeUnitWeek:
+		return time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	case solaredge.TimeUnitMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case solaredge.TimeUnitYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+func next(t time.Time, unit solaredge.TimeUnit) time.Time {
+	switch unit {
+	case solaredge.TimeUnitQuarter:
+		return t.Add(quarter)
+	case solaredge.TimeUnitHour:
+		return t.Add(time.Hour)
+	case solaredge.TimeUnitWeek:
+		return t.AddDate(0, 0, 7)
+	case solaredge.TimeUnitMonth:
+		return t.AddDate(0, 1, 0)
+	case solaredge.TimeUnitYear:
+		return t.AddDate(1, 0, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+func sortSites(sites solaredge.Sites) {
+	slices.SortFunc(sites, func(a, b solaredge.SiteDetails) int { return a.Id - b.Id })
+}
@@ -0,0 +1,331 @@
+/*
+Package solaredgetest provides a fake SolarEdge API server, to test code built on the solaredge client.
+
+The server is stateful: it serves a configurable set of sites, generates plausible time series for the requested
+time range and time unit, enforces the API's range limits and daily quota, checks the api_key of each request and
+allows errors to be injected:
+
+	s := solaredgetest.NewServer("API_KEY", solaredgetest.Site{Details: solaredge.SiteDetails{Id: 1, PeakPower: 5}})
+	defer s.Close()
+	c := solaredge.Client{SiteKey: "API_KEY", HTTPClient: s.Client()}
+	s.Fail("/site/1/overview", solaredgetest.Failure{StatusCode: http.StatusInternalServerError})
+
+Time series are modelled on a clear day: production follows the sun between 06:00 and 20:00 (site time), scaled by
+the site's peak power and the season. Consumption has a morning and an evening peak. Values are only generated up to
+the server's current time (see Server.Now).
+
+To test against real API responses instead, record them once with a Recorder and replay them offline with a Replayer.
+*/
+package solaredgetest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/internal/sitepath"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultDailyQuota is the number of requests the SolarEdge API allows per site per day. It equals
+// solaredge.DefaultDailyQuota.
+const DefaultDailyQuota = solaredge.DefaultDailyQuota
+
+// Site configures a site served by the Server.
+type Site struct {
+	// Details returned for the site. Details.Id identifies the site and must be set.
+	// Details.PeakPower (in kWp) scales the generated production and defaults to 5 kWp.
+	// Details.Location.TimeZone determines the site's local time and defaults to UTC.
+	// Details.InstallationDate is the start of the site's data period and defaults to a year before the server's current time.
+	Details solaredge.SiteDetails
+	// Inventory of the site. Inverter technical data is only served for the inverters in the inventory
+	// and storage data only for its batteries.
+	Inventory solaredge.Inventory
+	// SilentInverters holds the serial numbers of inverters in the inventory that stopped reporting: their technical
+	// data is empty.
+	SilentInverters []string
+}
+
+// Failure describes an error returned by the Server.
+type Failure struct {
+	// StatusCode of the response. Defaults to http.StatusInternalServerError.
+	StatusCode int
+	// Message and Description are returned in the body of the response.
+	Message     string
+	Description string
+	// HTML returns the error as an HTML page (as the SolarEdge API does), instead of a JSON object.
+	HTML bool
+	// Times is the number of requests that fail. Zero means the failure persists until cleared by ClearFailures.
+	Times int
+}
+
+// Server is a fake SolarEdge API server.
+type Server struct {
+	*httptest.Server
+	// APIKey that requests must provide.
+	APIKey string
+	// DailyQuota is the number of requests allowed per site per day. Requests that don't relate to a site (e.g. the
+	// list of sites) count against site 0. Defaults to DefaultDailyQuota. Set to a negative value to disable the quota.
+	DailyQuota int
+	// Now returns the server's current time. Defaults to time.Now.
+	Now func() time.Time
+
+	sites    map[int]*site
+	failures map[string]*Failure
+	requests map[int]int
+	day      time.Time
+	lock     sync.Mutex
+}
+
+// NewServer starts a Server serving the provided sites. The caller must call Close when finished.
+func NewServer(apiKey string, sites ...Site) *Server {
+	s := Server{
+		APIKey:   apiKey,
+		sites:    make(map[int]*site),
+		failures: make(map[string]*Failure),
+		requests: make(map[int]int),
+	}
+	for _, site := range sites {
+		s.AddSite(site)
+	}
+	s.Server = httptest.NewServer(s.handler())
+	return &s
+}
+
+// Client returns an HTTP client that sends all requests to the Server, regardless of the host in the request's URL.
+// Use it as the HTTPClient of a solaredge.Client.
+func (s *Server) Client() *http.Client {
+	target, _ := url.Parse(s.URL)
+	return &http.Client{Transport: redirectTransport{target: target, next: s.Server.Client().Transport}}
+}
+
+type redirectTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (r redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = r.target.Scheme
+	req.URL.Host = r.target.Host
+	req.Host = r.target.Host
+	return r.next.RoundTrip(req)
+}
+
+// AddSite adds a site to the Server, or replaces the site with the same ID.
+func (s *Server) AddSite(site Site) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.sites[site.Details.Id] = newSite(site)
+}
+
+// Fail makes requests for the provided path (e.g. "/site/1/overview") return an error.
+func (s *Server) Fail(path string, failure Failure) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.failures[path] = &failure
+}
+
+// ClearFailures removes all injected failures.
+func (s *Server) ClearFailures() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	clear(s.failures)
+}
+
+// Requests returns the number of requests received today for a site.
+func (s *Server) Requests(siteID int) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.resetQuota()
+	return s.requests[siteID]
+}
+
+func (s *Server) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *Server) handler() http.Handler {
+	m := http.NewServeMux()
+	m.HandleFunc("GET /sites/list", s.sitesList)
+	m.HandleFunc("GET /version/current", s.currentVersion)
+	m.HandleFunc("GET /version/supported", s.supportedVersions)
+	for path, h := range map[string]siteHandler{
+		"/site/{id}/details":                 siteDetails,
+		"/site/{id}/dataPeriod":              dataPeriod,
+		"/site/{id}/energy":                  energy,
+		"/site/{id}/timeFrameEnergy":         timeFrameEnergy,
+		"/site/{id}/power":                   power,
+		"/site/{id}/overview":                overview,
+		"/site/{id}/powerDetails":            powerDetails,
+		"/site/{id}/energyDetails":           energyDetails,
+		"/site/{id}/currentPowerFlow":        powerFlow,
+		"/site/{id}/storageData":             storageData,
+		"/site/{id}/envBenefits":             envBenefits,
+		"/equipment/{id}/list":               components,
+		"/site/{id}/inventory":               inventory,
+		"/equipment/{id}/{serial}/data":      inverterData,
+		"/equipment/{id}/{serial}/changeLog": changeLog,
+	} {
+		m.Handle("GET "+path, s.siteHandler(h))
+	}
+	return s.middleware(m)
+}
+
+// middleware checks the api key, applies the quota and injects failures.
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_key") != s.APIKey {
+			writeError(w, Failure{StatusCode: http.StatusForbidden, Message: "Invalid token", Description: "The api_key is not valid.", HTML: true})
+			return
+		}
+		if !s.count(sitepath.SiteID(r.URL.Path)) {
+			writeError(w, Failure{StatusCode: http.StatusTooManyRequests, Message: "Too many requests", Description: "The daily request quota has been exceeded.", HTML: true})
+			return
+		}
+		if f, ok := s.failure(r.URL.Path); ok {
+			writeError(w, f)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) count(siteID int) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.resetQuota()
+	quota := s.DailyQuota
+	if quota == 0 {
+		quota = DefaultDailyQuota
+	}
+	if quota > 0 && s.requests[siteID] >= quota {
+		return false
+	}
+	s.requests[siteID]++
+	return true
+}
+
+func (s *Server) resetQuota() {
+	now := s.now()
+	if day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()); !day.Equal(s.day) {
+		s.day = day
+		clear(s.requests)
+	}
+}
+
+func (s *Server) failure(path string) (Failure, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, ok := s.failures[path]
+	if !ok {
+		return Failure{}, false
+	}
+	if f.Times > 0 {
+		if f.Times--; f.Times == 0 {
+			delete(s.failures, path)
+		}
+	}
+	return *f, true
+}
+
+func (s *Server) sitesList(w http.ResponseWriter, _ *http.Request) {
+	var response solaredge.GetSitesResponse
+	s.lock.Lock()
+	for _, site := range s.sites {
+		response.Sites.Site = append(response.Sites.Site, site.Details)
+	}
+	s.lock.Unlock()
+	sortSites(response.Sites.Site)
+	response.Sites.Count = len(response.Sites.Site)
+	writeJSON(w, response)
+}
+
+func (s *Server) currentVersion(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, solaredge.GetCurrentAPIVersionResponse{Version: solaredge.APIRelease{Release: "1.0.0"}})
+}
+
+func (s *Server) supportedVersions(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, solaredge.GetSupportedAPIVersionsResponse{Supported: []solaredge.APIRelease{{Release: "1.0.0"}}})
+}
+
+// siteHandler handles a request for a site. It returns the response, or an error. If the error is a Failure, it
+// determines the response's status code and body.
+type siteHandler func(site *site, r *http.Request, now time.Time) (any, error)
+
+func (s *Server) siteHandler(h siteHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		s.lock.Lock()
+		site, ok := s.sites[id]
+		s.lock.Unlock()
+		if !ok {
+			writeError(w, Failure{StatusCode: http.StatusForbidden, Message: "Invalid site ID", Description: "The site ID is not valid or not accessible with this api_key.", HTML: true})
+			return
+		}
+		response, err := h(site, r, s.now())
+		if err != nil {
+			var f Failure
+			if !errors.As(err, &f) {
+				f = Failure{Message: err.Error()}
+			}
+			writeError(w, f)
+			return
+		}
+		writeJSON(w, response)
+	})
+}
+
+// Error implements the error interface, so handlers can return a Failure as an error.
+func (f Failure) Error() string {
+	return f.Message
+}
+
+func badRequest(format string, args ...any) Failure {
+	return Failure{StatusCode: http.StatusBadRequest, Message: fmt.Sprintf(format, args...), Description: "The request sent by the client was syntactically incorrect.", HTML: true}
+}
+
+func forbidden(format string, args ...any) Failure {
+	return Failure{StatusCode: http.StatusForbidden, Message: fmt.Sprintf(format, args...), Description: "Access to the specified resource has been forbidden.", HTML: true}
+}
+
+func writeJSON(w http.ResponseWriter, response any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func writeError(w http.ResponseWriter, f Failure) {
+	if f.StatusCode == 0 {
+		f.StatusCode = http.StatusInternalServerError
+	}
+	if f.Message == "" {
+		f.Message = http.StatusText(f.StatusCode)
+	}
+	if !f.HTML {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(f.StatusCode)
+		_ = json.NewEncoder(w).Encode(map[string]string{"message": f.Message, "description": f.Description})
+		return
+	}
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(f.StatusCode)
+	_, _ = fmt.Fprintf(w, `<html>
+<body>
+	<h1>HTTP Status %d – %s</h1>
+	<hr class="line" />
+	<p><b>Type</b> Status Report</p>
+	<p><b>Message</b> %s</p>
+	<p><b>Description</b> %s</p>
+	<hr class="line" />
+</body>
+</html>`, f.StatusCode, http.StatusText(f.StatusCode), html.EscapeString(f.Message), html.EscapeString(f.Description))
+}
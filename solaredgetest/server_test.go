@@ -0,0 +1,239 @@
+package solaredgetest_test
+
+import (
+	"context"
+	"errors"
+	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/solaredgetest"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const apiKey = "TEST_KEY"
+
+func newServer(t *testing.T) (*solaredgetest.Server, *solaredge.Client, time.Time) {
+	t.Helper()
+	var details solaredge.SiteDetails
+	details.Id = 1
+	details.Name = "home"
+	details.PeakPower = 4
+	details.Location.TimeZone = "Europe/Brussels"
+	details.InstallationDate = solaredge.Date(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	site := solaredgetest.Site{
+		Details: details,
+		Inventory: solaredge.Inventory{
+			Inverters: []solaredge.InverterEquipment{{SN: "SN1", Model: "SE4000H"}},
+			Batteries: []solaredge.BatteryEquipment{{SN: "BAT1", NameplateCapacity: 10000}},
+		},
+	}
+	loc, _ := time.LoadLocation("Europe/Brussels")
+	now := time.Date(2024, time.June, 15, 14, 0, 0, 0, loc)
+
+	s := solaredgetest.NewServer(apiKey, site)
+	t.Cleanup(s.Close)
+	s.Now = func() time.Time { return now }
+	return s, &solaredge.Client{SiteKey: apiKey, HTTPClient: s.Client()}, now
+}
+
+func TestServer_Sites(t *testing.T) {
+	s, c, _ := newServer(t)
+	s.AddSite(solaredgetest.Site{Details: solaredge.SiteDetails{Id: 2, Name: "work"}})
+	ctx := context.Background()
+
+	sites, err := c.GetSites(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sites.Sites.Count != 2 || sites.Sites.Site[0].Name != "home" || sites.Sites.Site[1].Name != "work" {
+		t.Errorf("unexpected sites: %v", sites.Sites)
+	}
+
+	details, err := c.GetSiteDetails(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Details.Name != "home" {
+		t.Errorf("got %q, want %q", details.Details.Name, "home")
+	}
+
+	if _, err = c.GetSiteDetails(ctx, 3); err == nil {
+		t.Error("expected an error for an unknown site")
+	}
+}
+
+func TestServer_Energy(t *testing.T) {
+	_, c, now := newServer(t)
+	ctx := context.Background()
+
+	energy, err := c.GetEnergyMeasurements(ctx, 1, solaredge.TimeUnitDay, now.AddDate(0, 0, -6), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(energy.Energy.Values); got != 7 {
+		t.Fatalf("got %d values, want 7", got)
+	}
+	// the last day is only partially done
+	if values := energy.Energy.Values; values[6].Value >= values[5].Value || values[5].Value <= 0 {
+		t.Errorf("unexpected values: %v", values)
+	}
+	if got := time.Time(energy.Energy.Values[0].Date); got != time.Date(2024, time.June, 9, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("unexpected start: %v", got)
+	}
+
+	details, err := c.GetEnergyDetails(ctx, 1, solaredge.TimeUnitHour, now.Add(-24*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(details.EnergyDetails.Meters) != 5 {
+		t.Fatalf("got %d meters, want 5", len(details.EnergyDetails.Meters))
+	}
+	for _, meter := range details.EnergyDetails.Meters {
+		if len(meter.Values) != 24 {
+			t.Errorf("%s: got %d values, want 24", meter.Type, len(meter.Values))
+		}
+	}
+
+	_, err = c.GetEnergyDetails(ctx, 1, solaredge.TimeUnitQuarter, now.AddDate(0, -2, 0), now)
+	if err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("expected a range error, got %v", err)
+	}
+
+	timeFrame, err := c.GetEnergyForTimeFrame(ctx, 1, now.AddDate(0, 0, -6), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total float64
+	for _, v := range energy.Energy.Values {
+		total += v.Value
+	}
+	if got := timeFrame.TimeFrameEnergy.Energy; math.Abs(got-total) > 0.01 {
+		t.Errorf("got %f, want %f", got, total)
+	}
+}
+
+func TestServer_Power(t *testing.T) {
+	_, c, now := newServer(t)
+	ctx := context.Background()
+
+	power, err := c.GetPowerMeasurements(ctx, 1, now.Add(-2*time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// values are only generated up to the current time
+	if got := len(power.Power.Values); got != 8 {
+		t.Errorf("got %d values, want 8", got)
+	}
+	for _, v := range power.Power.Values {
+		if v.Value <= 0 || v.Value > 4000 {
+			t.Errorf("unexpected value: %v", v)
+		}
+	}
+
+	flow, err := c.GetPowerFlow(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flow.CurrentPowerFlow.PV.CurrentPower <= 0 || flow.CurrentPowerFlow.Storage.ChargeLevel != 50 {
+		t.Errorf("unexpected power flow: %v", flow.CurrentPowerFlow)
+	}
+
+	overview, err := c.GetPowerOverview(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := overview.Overview
+	if o.LastDayData.Energy <= 0 || o.LastMonthData.Energy <= o.LastDayData.Energy || o.LifeTimeData.Energy != o.LastYearData.Energy {
+		t.Errorf("unexpected overview: %v", o)
+	}
+}
+
+func TestServer_Equipment(t *testing.T) {
+	_, c, now := newServer(t)
+	ctx := context.Background()
+
+	data, err := c.GetInverterTechnicalData(ctx, 1, "SN1", now.Add(-time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Data.Count != 4 || data.Data.Telemetries[0].InverterMode != "MPPT" {
+		t.Errorf("unexpected data: %v", data.Data)
+	}
+	if _, err = c.GetInverterTechnicalData(ctx, 1, "SN2", now.Add(-time.Hour), now); err == nil {
+		t.Error("expected an error for an unknown inverter")
+	}
+	if _, err = c.GetInverterTechnicalData(ctx, 1, "SN1", now.AddDate(0, 0, -8), now); err == nil {
+		t.Error("expected an error for a range exceeding one week")
+	}
+
+	storage, err := c.GetStorageData(ctx, 1, now.Add(-time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if storage.StorageData.BatteryCount != 1 || len(storage.StorageData.Batteries[0].Telemetries) != 4 {
+		t.Errorf("unexpected storage data: %v", storage.StorageData)
+	}
+
+	components, err := c.GetComponents(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if components.Reporters.Count != 1 || components.Reporters.List[0].SerialNumber != "SN1" {
+		t.Errorf("unexpected components: %v", components.Reporters)
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	s, c, _ := newServer(t)
+	ctx := context.Background()
+
+	bad := solaredge.Client{SiteKey: "invalid", HTTPClient: s.Client()}
+	if _, err := bad.GetSites(ctx); err == nil || !strings.Contains(err.Error(), "Invalid token") {
+		t.Errorf("expected an invalid token error, got %v", err)
+	}
+
+	s.Fail("/site/1/overview", solaredgetest.Failure{StatusCode: http.StatusServiceUnavailable, Message: "maintenance", Times: 1})
+	var apiErr *solaredge.Error
+	if _, err := c.GetPowerOverview(ctx, 1); !errors.As(err, &apiErr) || !strings.Contains(err.Error(), "maintenance") {
+		t.Errorf("expected an injected error, got %v", err)
+	}
+	if _, err := c.GetPowerOverview(ctx, 1); err != nil {
+		t.Errorf("expected the failure to be cleared, got %v", err)
+	}
+
+	s.Fail("/site/1/details", solaredgetest.Failure{Message: "failed", HTML: true})
+	for range 2 {
+		if _, err := c.GetSiteDetails(ctx, 1); err == nil || !strings.Contains(err.Error(), "failed") {
+			t.Errorf("expected an injected error, got %v", err)
+		}
+	}
+	s.ClearFailures()
+	if _, err := c.GetSiteDetails(ctx, 1); err != nil {
+		t.Errorf("expected the failure to be cleared, got %v", err)
+	}
+}
+
+func TestServer_Quota(t *testing.T) {
+	s, c, now := newServer(t)
+	s.DailyQuota = 2
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := c.GetSiteDetails(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.GetSiteDetails(ctx, 1); err == nil || !strings.Contains(err.Error(), "Too many requests") {
+		t.Errorf("expected a quota error, got %v", err)
+	}
+	if got := s.Requests(1); got != 2 {
+		t.Errorf("got %d requests, want 2", got)
+	}
+
+	s.Now = func() time.Time { return now.AddDate(0, 0, 1) }
+	if _, err := c.GetSiteDetails(ctx, 1); err != nil {
+		t.Errorf("expected the quota to be reset, got %v", err)
+	}
+}
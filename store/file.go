@@ -0,0 +1,278 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/clambin/solaredge/v2/internal/atomicfile"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var _ Store = &File{}
+
+// File is a Store that keeps each series in a file of JSON lines, one point per line. Files are stored per site:
+//
+//	<dir>/<siteID>/<type>.jsonl
+//	<dir>/<siteID>/<type>-<name>.jsonl
+//
+// The type of a Key may only hold letters, digits and underscores: the methods return ErrInvalidKey for other types.
+// The name may hold any character: it is escaped in the file name.
+//
+// Writes are appended to the file. When a series is read, later points replace earlier points with the same timestamp.
+// Use Compact to remove replaced points from a file. If a write is interrupted, the incomplete last line is ignored
+// when reading the series and removed by the next write.
+//
+// Read parses the series' whole file on each call, so File is best suited for series of moderate size (e.g. a few
+// years of quarter-hour data), read occasionally. All methods are safe for concurrent use.
+type File struct {
+	last map[Key]time.Time
+	dir  string
+	lock sync.Mutex
+}
+
+const fileExtension = ".jsonl"
+
+// ErrInvalidKey is returned by File if the type of a Key holds characters other than letters, digits and underscores.
+var ErrInvalidKey = errors.New("invalid series type")
+
+// NewFile returns a File store in the specified directory. The directory is created if it doesn't exist.
+func NewFile(dir string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &File{dir: dir, last: make(map[Key]time.Time)}, nil
+}
+
+// path returns the file of the series. The type must be safe to use in a file name and must not contain '-',
+// which separates the type from the name.
+func (f *File) path(key Key) (string, error) {
+	if !validType(key.Type) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key.Type)
+	}
+	name := key.Type
+	if key.Name != "" {
+		name += "-" + url.PathEscape(key.Name)
+	}
+	return filepath.Join(f.dir, strconv.Itoa(key.SiteID), name+fileExtension), nil
+}
+
+func validType(t string) bool {
+	if t == "" {
+		return false
+	}
+	for _, r := range t {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *File) Write(_ context.Context, key Key, points []Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if err = truncatePartialLine(file); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	for _, p := range points {
+		if err = enc.Encode(p); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err2 := file.Close(); err == nil {
+		err = err2
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+
+	if last, ok := f.last[key]; ok {
+		for _, p := range points {
+			if p.Time.After(last) {
+				last = p.Time
+			}
+		}
+		f.last[key] = last
+	}
+	return nil
+}
+
+func (f *File) Read(_ context.Context, key Key, start, end time.Time) ([]Point, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	series, err := f.load(key)
+	if err != nil {
+		return nil, err
+	}
+	return between(series, start, end), nil
+}
+
+func (f *File) Last(_ context.Context, key Key) (time.Time, bool, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if last, ok := f.last[key]; ok {
+		return last, true, nil
+	}
+	series, err := f.load(key)
+	if err != nil || len(series) == 0 {
+		return time.Time{}, false, err
+	}
+	last := series[len(series)-1].Time
+	f.last[key] = last
+	return last, true, nil
+}
+
+func (f *File) Keys(_ context.Context, siteID int) ([]Key, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	entries, err := os.ReadDir(filepath.Join(f.dir, strconv.Itoa(siteID)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]Key, 0, len(entries))
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), fileExtension)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		key := Key{SiteID: siteID, Type: name}
+		if seriesType, seriesName, ok := strings.Cut(name, "-"); ok {
+			if seriesName, err = url.PathUnescape(seriesName); err != nil {
+				continue
+			}
+			key.Type, key.Name = seriesType, seriesName
+		}
+		keys = append(keys, key)
+	}
+	return sortKeys(keys), nil
+}
+
+// Compact rewrites the file of the series, removing all points that were replaced by a later write.
+func (f *File) Compact(_ context.Context, key Key) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	series, err := f.load(key)
+	if err != nil || len(series) == 0 {
+		return err
+	}
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+	err = atomicfile.WriteFile(path, func(file io.Writer) error {
+		w := bufio.NewWriter(file)
+		enc := json.NewEncoder(w)
+		for _, p := range series {
+			if err := enc.Encode(p); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// load reads all points of the series from its file, in chronological order.
+func (f *File) load(key Key) ([]Point, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var points []Point
+	r := bufio.NewReader(file)
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// a last line without a newline is the result of an interrupted write: ignore it
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var p Point
+		if err = json.Unmarshal(line, &p); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		points = append(points, p)
+	}
+	return merge(nil, points), nil
+}
+
+// truncatePartialLine removes a last line without a newline, left behind by an interrupted write, so appended points
+// start on a new line.
+func truncatePartialLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	size := info.Size()
+	buf := make([]byte, 4096)
+	for end := size; end > 0; {
+		start := max(0, end-int64(len(buf)))
+		n, err := file.ReadAt(buf[:end-start], start)
+		if err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			if valid := start + int64(i) + 1; valid < size {
+				return file.Truncate(valid)
+			}
+			return nil
+		}
+		end = start
+	}
+	if size > 0 {
+		return file.Truncate(0)
+	}
+	return nil
+}
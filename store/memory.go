@@ -0,0 +1,53 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ Store = &Memory{}
+
+// Memory is a Store that keeps all series in memory. The zero value is ready for use.
+type Memory struct {
+	series map[Key][]Point
+	lock   sync.RWMutex
+}
+
+func (m *Memory) Write(_ context.Context, key Key, points []Point) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.series == nil {
+		m.series = make(map[Key][]Point)
+	}
+	m.series[key] = merge(m.series[key], points)
+	return nil
+}
+
+func (m *Memory) Read(_ context.Context, key Key, start, end time.Time) ([]Point, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return between(m.series[key], start, end), nil
+}
+
+func (m *Memory) Last(_ context.Context, key Key) (time.Time, bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	series := m.series[key]
+	if len(series) == 0 {
+		return time.Time{}, false, nil
+	}
+	return series[len(series)-1].Time, true, nil
+}
+
+func (m *Memory) Keys(_ context.Context, siteID int) ([]Key, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	var keys []Key
+	for key := range m.series {
+		if key.SiteID == siteID {
+			keys = append(keys, key)
+		}
+	}
+	return sortKeys(keys), nil
+}
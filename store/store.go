@@ -0,0 +1,99 @@
+/*
+Package store persists measurements retrieved from the SolarEdge API.
+
+A Store holds series of Points. Each series is identified by a Key: the site, the type of the series and, for series
+that exist more than once per site, a name (e.g. the meter type, or the serial number of an inverter or battery).
+
+Two implementations are provided: Memory, which keeps all data in memory and is intended for tests, and File, which
+stores each series in an append-only file of JSON lines.
+*/
+package store
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Series types.
+const (
+	// TypePower holds the site's power measurements.
+	TypePower = "power"
+	// TypeMeter holds the readings of a meter. The Key's name is the meter type (e.g. Production, FeedIn).
+	TypeMeter = "meter"
+	// TypeBattery holds a battery's telemetry. The Key's name is the serial number of the battery.
+	TypeBattery = "battery"
+	// TypeInverter holds an inverter's technical data. The Key's name is the serial number of the inverter.
+	TypeInverter = "inverter"
+)
+
+// Key identifies a series.
+type Key struct {
+	Type   string
+	Name   string
+	SiteID int
+}
+
+func (k Key) String() string {
+	s := strconv.Itoa(k.SiteID) + "/" + k.Type
+	if k.Name != "" {
+		s += "/" + k.Name
+	}
+	return s
+}
+
+// A Point holds one or more measurements at a moment in time.
+type Point struct {
+	Time   time.Time          `json:"time"`
+	Fields map[string]float64 `json:"fields"`
+}
+
+// Store persists series of Points.
+type Store interface {
+	// Write adds the points to the series. A point replaces any existing point in the series with the same timestamp.
+	Write(ctx context.Context, key Key, points []Point) error
+	// Read returns the points in the series with a timestamp in the range [start, end), in chronological order.
+	Read(ctx context.Context, key Key, start, end time.Time) ([]Point, error)
+	// Last returns the timestamp of the last point in the series. Returns false if the series has no points.
+	Last(ctx context.Context, key Key) (time.Time, bool, error)
+	// Keys returns the keys of all series of the site.
+	Keys(ctx context.Context, siteID int) ([]Key, error)
+}
+
+// merge adds the points to the series, replacing points with the same timestamp, and returns the sorted result.
+func merge(series []Point, points []Point) []Point {
+	byTime := make(map[int64]int, len(series))
+	for i, p := range series {
+		byTime[p.Time.UnixNano()] = i
+	}
+	for _, p := range points {
+		if i, ok := byTime[p.Time.UnixNano()]; ok {
+			series[i] = p
+			continue
+		}
+		byTime[p.Time.UnixNano()] = len(series)
+		series = append(series, p)
+	}
+	slices.SortFunc(series, func(a, b Point) int { return a.Time.Compare(b.Time) })
+	return series
+}
+
+// between returns the points with a timestamp in the range [start, end). series must be sorted.
+func between(series []Point, start, end time.Time) []Point {
+	from, _ := slices.BinarySearchFunc(series, start, func(p Point, t time.Time) int { return p.Time.Compare(t) })
+	to, _ := slices.BinarySearchFunc(series, end, func(p Point, t time.Time) int { return p.Time.Compare(t) })
+	if from >= to {
+		return nil
+	}
+	return slices.Clone(series[from:to])
+}
+
+func sortKeys(keys []Key) []Key {
+	slices.SortFunc(keys, func(a, b Key) int {
+		return cmp.Or(strings.Compare(a.Type, b.Type), strings.Compare(a.Name, b.Name))
+	})
+	return keys
+}
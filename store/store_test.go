@@ -0,0 +1,235 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func point(hour int, value float64) Point {
+	return Point{Time: time.Date(2024, time.June, 1, hour, 0, 0, 0, time.UTC), Fields: map[string]float64{"value": value}}
+}
+
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+	production := Key{SiteID: 1, Type: TypeMeter, Name: "Production"}
+	inverter := Key{SiteID: 1, Type: TypeInverter, Name: "7E/1234 5?"}
+	power := Key{SiteID: 1, Type: TypePower}
+
+	if _, ok, err := s.Last(ctx, production); err != nil || ok {
+		t.Fatalf("unexpected last timestamp: %v, %v", ok, err)
+	}
+	if err := s.Write(ctx, production, []Point{point(12, 1), point(10, 1), point(11, 1)}); err != nil {
+		t.Fatal(err)
+	}
+	// overwrites the point at 11:00
+	if err := s.Write(ctx, production, []Point{point(11, 2), point(13, 1)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(ctx, inverter, []Point{point(10, 3)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(ctx, power, []Point{point(10, 4)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(ctx, Key{SiteID: 2, Type: TypePower}, []Point{point(10, 4)}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Read(ctx, production, point(11, 0).Time, point(13, 0).Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Point{point(11, 2), point(12, 1)}; !equalPoints(got, want) {
+		t.Errorf("Read() = %v, want %v", got, want)
+	}
+
+	got, err = s.Read(ctx, inverter, point(0, 0).Time, point(23, 0).Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Point{point(10, 3)}; !equalPoints(got, want) {
+		t.Errorf("Read() = %v, want %v", got, want)
+	}
+
+	if got, err = s.Read(ctx, production, point(20, 0).Time, point(23, 0).Time); err != nil || len(got) != 0 {
+		t.Errorf("Read() = %v, %v", got, err)
+	}
+
+	last, ok, err := s.Last(ctx, production)
+	if err != nil || !ok || !last.Equal(point(13, 0).Time) {
+		t.Errorf("Last() = %v, %v, %v", last, ok, err)
+	}
+
+	keys, err := s.Keys(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Key{inverter, production, power}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Keys() = %v, want %v", keys, want)
+	}
+}
+
+func equalPoints(got, want []Point) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if !got[i].Time.Equal(want[i].Time) || !reflect.DeepEqual(got[i].Fields, want[i].Fields) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestKey_String(t *testing.T) {
+	if got := (Key{SiteID: 1, Type: TypePower}).String(); got != "1/power" {
+		t.Errorf("got %q", got)
+	}
+	if got := (Key{SiteID: 1, Type: TypeInverter, Name: "SN1"}).String(); got != "1/inverter/SN1" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testStore(t, &Memory{})
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, s)
+
+	// data survives a restart
+	ctx := context.Background()
+	key := Key{SiteID: 1, Type: TypeMeter, Name: "Production"}
+	if s, err = NewFile(dir); err != nil {
+		t.Fatal(err)
+	}
+	if last, ok, err := s.Last(ctx, key); err != nil || !ok || !last.Equal(point(13, 0).Time) {
+		t.Errorf("Last() = %v, %v, %v", last, ok, err)
+	}
+
+	// compacting removes overwritten points
+	before, err := os.Stat(filePath(t, s, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Compact(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.Stat(filePath(t, s, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() >= before.Size() {
+		t.Errorf("expected compacted file to be smaller: %d >= %d", after.Size(), before.Size())
+	}
+	got, err := s.Read(ctx, key, point(0, 0).Time, point(23, 0).Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Point{point(10, 1), point(11, 2), point(12, 1), point(13, 1)}; !equalPoints(got, want) {
+		t.Errorf("Read() = %v, want %v", got, want)
+	}
+
+	if keys, err := s.Keys(ctx, 3); err != nil || len(keys) != 0 {
+		t.Errorf("Keys() = %v, %v", keys, err)
+	}
+
+	if err = os.WriteFile(filePath(t, s, key), []byte("not json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Read(ctx, key, point(0, 0).Time, point(23, 0).Time); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func filePath(t *testing.T, s *File, key Key) string {
+	t.Helper()
+	path, err := s.path(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFile_InvalidKey(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewFile(filepath.Join(dir, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, seriesType := range []string{"", "../escape", "a/b", "meter-daily", "."} {
+		key := Key{SiteID: 1, Type: seriesType}
+		if err = s.Write(ctx, key, []Point{point(10, 1)}); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Write(%q): expected ErrInvalidKey, got %v", seriesType, err)
+		}
+		if _, err = s.Read(ctx, key, point(0, 0).Time, point(23, 0).Time); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Read(%q): expected ErrInvalidKey, got %v", seriesType, err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected only the store directory, got %v", entries)
+	}
+
+	// names may hold any character: they are escaped and read back unchanged
+	key := Key{SiteID: 1, Type: TypeMeter, Name: "../a-b/c"}
+	if err = s.Write(ctx, key, []Point{point(10, 1)}); err != nil {
+		t.Fatal(err)
+	}
+	if keys, err := s.Keys(ctx, 1); err != nil || !reflect.DeepEqual(keys, []Key{key}) {
+		t.Errorf("Keys() = %v, %v", keys, err)
+	}
+}
+
+func TestFile_InterruptedWrite(t *testing.T) {
+	s, err := NewFile(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	key := Key{SiteID: 1, Type: TypePower}
+	if err = s.Write(ctx, key, []Point{point(10, 1)}); err != nil {
+		t.Fatal(err)
+	}
+	// simulate a write that was interrupted halfway through a line
+	file, err := os.OpenFile(filePath(t, s, key), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = file.WriteString(`{"time":"2024-06-01T11:00:00Z","fiel`)
+	_ = file.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Read(ctx, key, point(0, 0).Time, point(23, 0).Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Point{point(10, 1)}; !equalPoints(got, want) {
+		t.Errorf("Read() = %v, want %v", got, want)
+	}
+
+	// the next write removes the incomplete line
+	if err = s.Write(ctx, key, []Point{point(12, 2)}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = s.Read(ctx, key, point(0, 0).Time, point(23, 0).Time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Point{point(10, 1), point(12, 2)}; !equalPoints(got, want) {
+		t.Errorf("Read() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,289 @@
+package solaredge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"net/url"
+	"time"
+)
+
+// The Stream methods are variants of the Get methods that yield the values of the response while it is being decoded,
+// rather than decoding the whole response into memory. Use them for long time ranges on large sites, e.g. when
+// backfilling history.
+//
+// If the request fails, or the response can't be decoded, the iterator yields the error and stops.
+// If the Client was created WithNormalizeUnits, values are converted to their base unit. This requires the unit to precede the
+// values in the response, which is how the API reports them.
+
+// MeterValue is a value reported by a meter, as yielded by StreamPowerDetails and StreamEnergyDetails.
+type MeterValue struct {
+	// Type of the meter, e.g. Production or FeedIn.
+	Type string
+	Value
+}
+
+// StreamPowerMeasurements is the streaming variant of GetPowerMeasurements.
+func (c *Client) StreamPowerMeasurements(ctx context.Context, id int, startTime, endTime time.Time) iter.Seq2[Value, error] {
+	args := url.Values{
+		"startTime": []string{startTime.Format(timeFormat)},
+		"endTime":   []string{endTime.Format(timeFormat)},
+	}
+	return stream(ctx, c, makePath("/site/{siteId}/power", id), args, func(d *streamDecoder, yield func(Value, error) bool) error {
+		return d.values([]string{"power", "values"}, yield)
+	})
+}
+
+// StreamEnergyMeasurements is the streaming variant of GetEnergyMeasurements.
+func (c *Client) StreamEnergyMeasurements(ctx context.Context, id int, timeUnit TimeUnit, startDate time.Time, endDate time.Time) iter.Seq2[Value, error] {
+	args := url.Values{
+		"startDate": []string{startDate.Format(time.DateOnly)},
+		"endDate":   []string{endDate.Format(time.DateOnly)},
+		"timeUnit":  []string{string(timeUnit)},
+	}
+	return stream(ctx, c, makePath("/site/{siteId}/energy", id), args, func(d *streamDecoder, yield func(Value, error) bool) error {
+		return d.values([]string{"energy", "values"}, yield)
+	})
+}
+
+// StreamPowerDetails is the streaming variant of GetPowerDetails. Values are yielded meter by meter.
+func (c *Client) StreamPowerDetails(ctx context.Context, id int, start, end time.Time) iter.Seq2[MeterValue, error] {
+	args := url.Values{
+		"startTime": []string{start.Format(timeFormat)},
+		"endTime":   []string{end.Format(timeFormat)},
+	}
+	return stream(ctx, c, makePath("/site/{siteId}/powerDetails", id), args, func(d *streamDecoder, yield func(MeterValue, error) bool) error {
+		return d.meterValues("powerDetails", yield)
+	})
+}
+
+// StreamEnergyDetails is the streaming variant of GetEnergyDetails. Values are yielded meter by meter.
+func (c *Client) StreamEnergyDetails(ctx context.Context, id int, timeUnit TimeUnit, startTime, endTime time.Time) iter.Seq2[MeterValue, error] {
+	args := url.Values{
+		"startTime": []string{startTime.Format(timeFormat)},
+		"endTime":   []string{endTime.Format(timeFormat)},
+		"timeUnit":  []string{string(timeUnit)},
+	}
+	return stream(ctx, c, makePath("/site/{siteId}/energyDetails", id), args, func(d *streamDecoder, yield func(MeterValue, error) bool) error {
+		return d.meterValues("energyDetails", yield)
+	})
+}
+
+// StreamInverterTechnicalData is the streaming variant of GetInverterTechnicalData.
+func (c *Client) StreamInverterTechnicalData(ctx context.Context, id int, serialNr string, startTime, endTime time.Time) iter.Seq2[InverterTelemetry, error] {
+	if err := validateSerialNumber(serialNr); err != nil {
+		return func(yield func(InverterTelemetry, error) bool) { yield(InverterTelemetry{}, err) }
+	}
+	args := url.Values{
+		"startTime": []string{startTime.Format(timeFormat)},
+		"endTime":   []string{endTime.Format(timeFormat)},
+	}
+	return stream(ctx, c, makePath("/equipment/{siteId}/{serialNumber}/data", id, serialNr), args, func(d *streamDecoder, yield func(InverterTelemetry, error) bool) error {
+		found, err := d.enter("data", "telemetries")
+		if err != nil || !found {
+			return err
+		}
+		return d.array(func() error {
+			var t InverterTelemetry
+			if err := d.dec.Decode(&t); err != nil {
+				return err
+			}
+			return d.yield(yield(t, nil))
+		})
+	})
+}
+
+// errStopped is returned by a streamDecoder when the consumer of the iterator stopped the iteration.
+var errStopped = errors.New("stopped")
+
+// stream calls the API and passes the response's decoder to decode, which yields the values.
+func stream[T any](ctx context.Context, c *Client, path string, args url.Values, decode func(*streamDecoder, func(T, error) bool) error) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		resp, cancel, err := c.do(ctx, path, args)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer cancel()
+		defer func() { _ = resp.Body.Close() }()
+
+		d := streamDecoder{dec: json.NewDecoder(resp.Body), normalize: c.normalizeUnits}
+		if err = decode(&d, yield); err != nil && !errors.Is(err, errStopped) {
+			yield(zero, err)
+		}
+	}
+}
+
+// streamDecoder decodes a response token by token.
+type streamDecoder struct {
+	dec *json.Decoder
+	// unit is the last unit found while descending into the response
+	unit      Unit
+	normalize bool
+}
+
+// enter descends into the response, object by object, following the keys. It returns false if a key isn't found.
+// On success, the decoder is positioned at the value of the last key. Any unit encountered along the way is recorded.
+func (d *streamDecoder) enter(keys ...string) (bool, error) {
+	for _, key := range keys {
+		found, err := d.find(key)
+		if err != nil || !found {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// find looks for the key in the object at the decoder's position. It returns false if the object doesn't contain
+// the key (or is null).
+func (d *streamDecoder) find(key string) (bool, error) {
+	ok, err := d.open('{')
+	if err != nil || !ok {
+		return false, err
+	}
+	for d.dec.More() {
+		k, err := d.key()
+		if err != nil {
+			return false, err
+		}
+		switch k {
+		case key:
+			return true, nil
+		case "unit":
+			err = d.dec.Decode(&d.unit)
+		default:
+			err = d.dec.Decode(&json.RawMessage{})
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// open consumes the opening delimiter of an object or array. It returns false if the value is null.
+func (d *streamDecoder) open(delim json.Delim) (bool, error) {
+	token, err := d.dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if token == nil {
+		return false, nil
+	}
+	if token != delim {
+		return false, fmt.Errorf("invalid response: expected %q, got %v", delim, token)
+	}
+	return true, nil
+}
+
+func (d *streamDecoder) key() (string, error) {
+	token, err := d.dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid response: expected a key, got %v", token)
+	}
+	return key, nil
+}
+
+// array calls f for each element of the array at the decoder's position. A null array is treated as empty.
+func (d *streamDecoder) array(f func() error) error {
+	ok, err := d.open('[')
+	if err != nil || !ok {
+		return err
+	}
+	for d.dec.More() {
+		if err = f(); err != nil {
+			return err
+		}
+	}
+	_, err = d.dec.Token()
+	return err
+}
+
+// factor returns the factor to convert values to their base unit, if the Client normalizes units.
+func (d *streamDecoder) factor() float64 {
+	if info, ok := d.unit.info(); ok && d.normalize {
+		return info.factor
+	}
+	return 1
+}
+
+func (d *streamDecoder) yield(more bool) error {
+	if !more {
+		return errStopped
+	}
+	return nil
+}
+
+// values yields the Values of the array found at the keys.
+func (d *streamDecoder) values(keys []string, yield func(Value, error) bool) error {
+	found, err := d.enter(keys...)
+	if err != nil || !found {
+		return err
+	}
+	return d.array(func() error {
+		var v Value
+		if err := d.dec.Decode(&v); err != nil {
+			return err
+		}
+		v.Value *= d.factor()
+		return d.yield(yield(v, nil))
+	})
+}
+
+// meterValues yields the values of each meter in the array found at <key>.meters.
+func (d *streamDecoder) meterValues(key string, yield func(MeterValue, error) bool) error {
+	found, err := d.enter(key, "meters")
+	if err != nil || !found {
+		return err
+	}
+	return d.array(func() error {
+		if ok, err := d.open('{'); err != nil || !ok {
+			return err
+		}
+		var meterType string
+		// values that precede the meter's type are yielded once the type is known
+		var pending []Value
+		for d.dec.More() {
+			k, err := d.key()
+			if err != nil {
+				return err
+			}
+			switch k {
+			case "type":
+				err = d.dec.Decode(&meterType)
+			case "values":
+				err = d.array(func() error {
+					var v Value
+					if err := d.dec.Decode(&v); err != nil {
+						return err
+					}
+					v.Value *= d.factor()
+					if meterType == "" {
+						pending = append(pending, v)
+						return nil
+					}
+					return d.yield(yield(MeterValue{Type: meterType, Value: v}, nil))
+				})
+			default:
+				err = d.dec.Decode(&json.RawMessage{})
+			}
+			if err != nil {
+				return err
+			}
+		}
+		for _, v := range pending {
+			if !yield(MeterValue{Type: meterType, Value: v}, nil) {
+				return errStopped
+			}
+		}
+		_, err := d.dec.Token()
+		return err
+	})
+}
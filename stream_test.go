@@ -0,0 +1,125 @@
+package solaredge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func streamServer(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func collect[T any](t *testing.T, seq func(func(T, error) bool)) ([]T, error) {
+	t.Helper()
+	var values []T
+	for v, err := range seq {
+		if err != nil {
+			return values, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func TestClient_StreamPowerDetails(t *testing.T) {
+	s := streamServer(`{"powerDetails":{"timeUnit":"QUARTER_OF_AN_HOUR","unit":"kW","meters":[
+		{"type":"Production","values":[{"date":"2024-06-01 12:00:00","value":1.5},{"date":"2024-06-01 12:15:00","value":2}]},
+		{"values":[{"date":"2024-06-01 12:00:00","value":0.5}],"type":"FeedIn"},
+		null,
+		{"type":"Purchased","values":null}
+	]}}`)
+	defer s.Close()
+
+	ts := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	c := Client{baseURL: s.URL, normalizeUnits: true}
+	got, err := collect(t, c.StreamPowerDetails(context.Background(), 1, ts, ts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MeterValue{
+		{Type: "Production", Value: Value{Date: Time(ts), Value: 1500}},
+		{Type: "Production", Value: Value{Date: Time(ts.Add(15 * time.Minute)), Value: 2000}},
+		{Type: "FeedIn", Value: Value{Date: Time(ts), Value: 500}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// stop early
+	var count int
+	for range c.StreamPowerDetails(context.Background(), 1, ts, ts) {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("got %d values, want 1", count)
+	}
+}
+
+func TestClient_StreamPowerMeasurements(t *testing.T) {
+	c := Client{baseURL: testServer.URL}
+	got, err := collect(t, c.StreamPowerMeasurements(context.Background(), 1, time.Time{}, time.Time{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := testResponses["/site/1/power"].(GetPowerMeasurementsResponse).Power.Values; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got, err = collect(t, c.StreamEnergyMeasurements(context.Background(), 1, TimeUnitDay, time.Time{}, time.Time{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := testResponses["/site/1/energy"].(GetEnergyMeasurementsResponse).Energy.Values; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestClient_StreamInverterTechnicalData(t *testing.T) {
+	c := Client{baseURL: testServer.URL}
+	got, err := collect(t, c.StreamInverterTechnicalData(context.Background(), 1, "SN1", time.Time{}, time.Time{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := testResponses["/equipment/1/SN1/data"].(GetInverterTechnicalDataResponse).Data.Telemetries; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err = collect(t, c.StreamInverterTechnicalData(context.Background(), 1, "..", time.Time{}, time.Time{})); !errors.Is(err, ErrInvalidSerialNumber) {
+		t.Errorf("expected ErrInvalidSerialNumber, got %v", err)
+	}
+}
+
+func TestClient_Stream_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "truncated", body: `{"energyDetails":{"unit":"Wh","meters":[{"type":"Production","values":[{"date":"2024-06-01 12:00:00","value":1}`},
+		{name: "invalid value", body: `{"energyDetails":{"meters":[{"type":"Production","values":[{"date":"yesterday","value":1}]}]}}`},
+		{name: "not an object", body: `{"energyDetails":[]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := streamServer(tt.body)
+			defer s.Close()
+			c := Client{baseURL: s.URL}
+			if _, err := collect(t, c.StreamEnergyDetails(context.Background(), 1, TimeUnitDay, time.Time{}, time.Time{})); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	c := Client{baseURL: testServer.URL}
+	if _, err := collect(t, c.StreamEnergyDetails(context.Background(), 2, TimeUnitDay, time.Time{}, time.Time{})); err == nil {
+		t.Error("expected an error")
+	}
+}
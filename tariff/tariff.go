@@ -0,0 +1,222 @@
+/*
+Package tariff calculates the cost of purchased energy, the revenue of fed-in energy and the savings of self-consumed
+energy of a site, based on time-of-use tariffs.
+
+The calculation uses the meters returned by solaredge.Client.GetEnergyDetails. For time-of-use tariffs to be applied
+correctly, the energy details must be reported at TimeUnitQuarter or TimeUnitHour resolution.
+*/
+package tariff
+
+import (
+	"errors"
+	"fmt"
+	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/analytics"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Rate is a price per kWh that applies during part of the day, on some days of the week.
+type Rate struct {
+	// Price per kWh.
+	Price float64
+	// From and To are the start and end of the time window during which the rate applies, as an offset since midnight.
+	// If To is before From, the window wraps around midnight. If both are zero, the rate applies all day.
+	From time.Duration
+	To   time.Duration
+	// Weekdays on which the rate applies. If empty, the rate applies every day.
+	Weekdays []time.Weekday
+}
+
+func (r Rate) appliesAt(t time.Time) bool {
+	if len(r.Weekdays) > 0 && !slices.Contains(r.Weekdays, t.Weekday()) {
+		return false
+	}
+	if r.From == 0 && r.To == 0 {
+		return true
+	}
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if r.From <= r.To {
+		return offset >= r.From && offset < r.To
+	}
+	return offset >= r.From || offset < r.To
+}
+
+// Schedule determines the price per kWh at any moment in time.
+type Schedule struct {
+	// Rates are evaluated in order. The first Rate that applies determines the price.
+	Rates []Rate
+	// Default is the price per kWh if none of the Rates apply.
+	Default float64
+}
+
+// PriceAt returns the price per kWh at the specified time. t should be expressed in the site's time zone.
+func (s Schedule) PriceAt(t time.Time) float64 {
+	for _, r := range s.Rates {
+		if r.appliesAt(t) {
+			return r.Price
+		}
+	}
+	return s.Default
+}
+
+// Tariff describes the energy contract of a site.
+type Tariff struct {
+	// Import determines the price paid for energy purchased from the grid.
+	Import Schedule
+	// FeedIn determines the price received for energy fed into the grid.
+	FeedIn Schedule
+	// FixedFee is charged once per billing period, regardless of consumption.
+	FixedFee float64
+	// BillingPeriod is the TimeUnit of the reported billing periods. Defaults to TimeUnitMonth.
+	BillingPeriod solaredge.TimeUnit
+	// Location is the site's time zone. Used to evaluate time-of-use rates and to align billing periods.
+	// Defaults to UTC.
+	Location *time.Location
+}
+
+// Bill holds the energy flows and their financial value for one billing period. Energy is reported in kWh.
+type Bill struct {
+	Start           time.Time
+	End             time.Time
+	Purchased       float64
+	FeedIn          float64
+	SelfConsumption float64
+	// ImportCost is the cost of the purchased energy.
+	ImportCost float64
+	// FeedInRevenue is the revenue of the energy fed into the grid.
+	FeedInRevenue float64
+	// Savings is the cost avoided by consuming self-produced energy, i.e. the self-consumed energy at import prices.
+	Savings float64
+	// FixedFees are the fixed fees charged for the billing period.
+	FixedFees float64
+}
+
+// Net returns the amount due for the billing period. A negative amount means the site earned more than it paid.
+func (b Bill) Net() float64 {
+	return b.ImportCost + b.FixedFees - b.FeedInRevenue
+}
+
+// WithoutSolar returns the amount that would have been due if all consumed energy had been purchased from the grid.
+func (b Bill) WithoutSolar() float64 {
+	return b.ImportCost + b.Savings + b.FixedFees
+}
+
+// Benefit returns the financial benefit of the installation for the billing period: the avoided import cost plus the
+// revenue of fed-in energy.
+func (b Bill) Benefit() float64 {
+	return b.Savings + b.FeedInRevenue
+}
+
+// ErrMissingMeters is returned when the energy details do not allow the Purchased, FeedIn and SelfConsumption
+// energy to be determined.
+var ErrMissingMeters = errors.New("energy details lack the meters to determine purchased, fed-in and self-consumed energy")
+
+// ErrTimeUnit is returned when the energy details are too coarse to apply time-of-use rates.
+var ErrTimeUnit = errors.New("energy details must have a time unit of QUARTER_OF_AN_HOUR or HOUR")
+
+// Calculate applies the tariff to the energy details and returns a Bill for each billing period. The energy details
+// must be reported per quarter or per hour: for coarser time units, Calculate returns ErrTimeUnit.
+func (t Tariff) Calculate(details solaredge.EnergyDetails) ([]Bill, error) {
+	if details.TimeUnit != solaredge.TimeUnitQuarter && details.TimeUnit != solaredge.TimeUnitHour {
+		return nil, fmt.Errorf("%w: got %q", ErrTimeUnit, details.TimeUnit)
+	}
+	factor, err := solaredge.Quantity{Value: 1, Unit: details.Unit}.Convert(solaredge.UnitKWh)
+	if err != nil {
+		return nil, fmt.Errorf("energy details: %w", err)
+	}
+	loc := t.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	billingPeriod := t.BillingPeriod
+	if billingPeriod == "" {
+		billingPeriod = solaredge.TimeUnitMonth
+	}
+
+	bills := make(map[time.Time]*Bill)
+	for _, b := range analytics.AnalyzeEnergy(details).Periods {
+		if !b.Has(analytics.MeterPurchased) || !b.Has(analytics.MeterFeedIn) || !b.Has(analytics.MeterSelfConsumption) {
+			return nil, ErrMissingMeters
+		}
+		local := time.Date(b.Start.Year(), b.Start.Month(), b.Start.Day(), b.Start.Hour(), b.Start.Minute(), b.Start.Second(), 0, loc)
+		start := billingPeriodStart(local, billingPeriod)
+		bill, ok := bills[start]
+		if !ok {
+			bill = &Bill{Start: start, End: billingPeriodEnd(start, billingPeriod), FixedFees: t.FixedFee}
+			bills[start] = bill
+		}
+		importPrice := t.Import.PriceAt(local)
+		purchased, feedIn, selfConsumption := b.Purchased*factor.Value, b.FeedIn*factor.Value, b.SelfConsumption*factor.Value
+		bill.Purchased += purchased
+		bill.FeedIn += feedIn
+		bill.SelfConsumption += selfConsumption
+		bill.ImportCost += purchased * importPrice
+		bill.FeedInRevenue += feedIn * t.FeedIn.PriceAt(local)
+		bill.Savings += selfConsumption * importPrice
+	}
+
+	result := make([]Bill, 0, len(bills))
+	for _, start := range slices.SortedFunc(maps.Keys(bills), time.Time.Compare) {
+		result = append(result, *bills[start])
+	}
+	return result, nil
+}
+
+// billingPeriodStart returns the start of the billing period containing t, aligned to calendar boundaries in t's
+// location: days start at midnight, weeks on Monday, etc.
+func billingPeriodStart(t time.Time, unit solaredge.TimeUnit) time.Time {
+	sinceHour := time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+	switch unit {
+	case solaredge.TimeUnitQuarter:
+		return t.Add(-(sinceHour % (15 * time.Minute)))
+	case solaredge.TimeUnitHour:
+		return t.Add(-sinceHour)
+	case solaredge.TimeUnitWeek:
+		return time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	case solaredge.TimeUnitMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case solaredge.TimeUnitYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+// billingPeriodEnd returns the end of the billing period starting at start.
+func billingPeriodEnd(start time.Time, unit solaredge.TimeUnit) time.Time {
+	switch unit {
+	case solaredge.TimeUnitQuarter:
+		return start.Add(15 * time.Minute)
+	case solaredge.TimeUnitHour:
+		return start.Add(time.Hour)
+	case solaredge.TimeUnitWeek:
+		return start.AddDate(0, 0, 7)
+	case solaredge.TimeUnitMonth:
+		return start.AddDate(0, 1, 0)
+	case solaredge.TimeUnitYear:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// Total adds up a list of bills into a single Bill covering all billing periods.
+func Total(bills []Bill) Bill {
+	var total Bill
+	for i, b := range bills {
+		if i == 0 {
+			total.Start = b.Start
+		}
+		total.End = b.End
+		total.Purchased += b.Purchased
+		total.FeedIn += b.FeedIn
+		total.SelfConsumption += b.SelfConsumption
+		total.ImportCost += b.ImportCost
+		total.FeedInRevenue += b.FeedInRevenue
+		total.Savings += b.Savings
+		total.FixedFees += b.FixedFees
+	}
+	return total
+}
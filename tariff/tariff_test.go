@@ -0,0 +1,133 @@
+package tariff
+
+import (
+	"errors"
+	"github.com/clambin/solaredge/v2"
+	"github.com/clambin/solaredge/v2/analytics"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSchedule_PriceAt(t *testing.T) {
+	s := Schedule{
+		Default: 0.30,
+		Rates: []Rate{
+			{Price: 0.10, Weekdays: []time.Weekday{time.Saturday, time.Sunday}},
+			{Price: 0.20, From: 22 * time.Hour, To: 7 * time.Hour},
+			{Price: 0.40, From: 17 * time.Hour, To: 20 * time.Hour},
+		},
+	}
+	tests := []struct {
+		name string
+		t    time.Time
+		want float64
+	}{
+		{name: "weekend", t: time.Date(2024, time.June, 1, 18, 0, 0, 0, time.UTC), want: 0.10},
+		{name: "night (before midnight)", t: time.Date(2024, time.June, 3, 23, 0, 0, 0, time.UTC), want: 0.20},
+		{name: "night (after midnight)", t: time.Date(2024, time.June, 4, 6, 45, 0, 0, time.UTC), want: 0.20},
+		{name: "peak", t: time.Date(2024, time.June, 4, 17, 0, 0, 0, time.UTC), want: 0.40},
+		{name: "end of peak", t: time.Date(2024, time.June, 4, 20, 0, 0, 0, time.UTC), want: 0.30},
+		{name: "default", t: time.Date(2024, time.June, 4, 12, 0, 0, 0, time.UTC), want: 0.30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.PriceAt(tt.t); got != tt.want {
+				t.Errorf("PriceAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTariff_Calculate(t *testing.T) {
+	ts := func(month time.Month, day, hour int) solaredge.Time {
+		return solaredge.Time(time.Date(2024, month, day, hour, 0, 0, 0, time.UTC))
+	}
+	details := solaredge.EnergyDetails{
+		TimeUnit: solaredge.TimeUnitQuarter,
+		Unit:     solaredge.UnitWh,
+		Meters: []solaredge.MeterReadings{
+			{Type: analytics.MeterPurchased, Values: []solaredge.Value{
+				{Date: ts(time.May, 31, 3), Value: 1000},
+				{Date: ts(time.June, 3, 3), Value: 2000},
+				{Date: ts(time.June, 3, 12), Value: 0},
+			}},
+			{Type: analytics.MeterFeedIn, Values: []solaredge.Value{
+				{Date: ts(time.May, 31, 3), Value: 0},
+				{Date: ts(time.June, 3, 3), Value: 0},
+				{Date: ts(time.June, 3, 12), Value: 4000},
+			}},
+			{Type: analytics.MeterSelfConsumption, Values: []solaredge.Value{
+				{Date: ts(time.May, 31, 3), Value: 0},
+				{Date: ts(time.June, 3, 3), Value: 0},
+				{Date: ts(time.June, 3, 12), Value: 1000},
+			}},
+		},
+	}
+	tariff := Tariff{
+		Import:   Schedule{Default: 0.30, Rates: []Rate{{Price: 0.20, From: 22 * time.Hour, To: 7 * time.Hour}}},
+		FeedIn:   Schedule{Default: 0.05},
+		FixedFee: 10,
+	}
+
+	bills, err := tariff.Calculate(details)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bills) != 2 {
+		t.Fatalf("got %d bills, want 2", len(bills))
+	}
+
+	want := []Bill{
+		{Purchased: 1, ImportCost: 0.2, FixedFees: 10},
+		{Purchased: 2, FeedIn: 4, SelfConsumption: 1, ImportCost: 0.4, FeedInRevenue: 0.2, Savings: 0.3, FixedFees: 10},
+	}
+	for i, b := range bills {
+		w := want[i]
+		if b.Purchased != w.Purchased || b.FeedIn != w.FeedIn || b.SelfConsumption != w.SelfConsumption ||
+			!equal(b.ImportCost, w.ImportCost) || !equal(b.FeedInRevenue, w.FeedInRevenue) || !equal(b.Savings, w.Savings) ||
+			b.FixedFees != w.FixedFees {
+			t.Errorf("bill %d: got %+v, want %+v", i, b, w)
+		}
+	}
+	if got := bills[1].Net(); !equal(got, 10.2) {
+		t.Errorf("Net() = %v, want 10.2", got)
+	}
+	if got := bills[1].WithoutSolar(); !equal(got, 10.7) {
+		t.Errorf("WithoutSolar() = %v, want 10.7", got)
+	}
+	if got := bills[1].Benefit(); !equal(got, 0.5) {
+		t.Errorf("Benefit() = %v, want 0.5", got)
+	}
+
+	total := Total(bills)
+	if total.Purchased != 3 || total.FixedFees != 20 || !total.Start.Equal(bills[0].Start) || !total.End.Equal(bills[1].End) {
+		t.Errorf("unexpected total: %+v", total)
+	}
+}
+
+func TestTariff_Calculate_Errors(t *testing.T) {
+	details := solaredge.EnergyDetails{
+		TimeUnit: solaredge.TimeUnitQuarter,
+		Unit:     solaredge.UnitWh,
+		Meters: []solaredge.MeterReadings{
+			{Type: analytics.MeterProduction, Values: []solaredge.Value{{Value: 1}}},
+		},
+	}
+	if _, err := (Tariff{}).Calculate(details); !errors.Is(err, ErrMissingMeters) {
+		t.Errorf("got error %v, want %v", err, ErrMissingMeters)
+	}
+	details.TimeUnit = solaredge.TimeUnitDay
+	if _, err := (Tariff{}).Calculate(details); !errors.Is(err, ErrTimeUnit) {
+		t.Errorf("got error %v, want %v", err, ErrTimeUnit)
+	}
+	details.TimeUnit = solaredge.TimeUnitHour
+	details.Unit = solaredge.UnitW
+	if _, err := (Tariff{}).Calculate(details); err == nil {
+		t.Error("expected an error for a power unit")
+	}
+}
+
+func equal(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
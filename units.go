@@ -0,0 +1,176 @@
+package solaredge
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unit is a unit of measurement, as reported by the SolarEdge API.
+type Unit string
+
+const (
+	UnitW   Unit = "W"
+	UnitKW  Unit = "kW"
+	UnitMW  Unit = "MW"
+	UnitGW  Unit = "GW"
+	UnitWh  Unit = "Wh"
+	UnitKWh Unit = "kWh"
+	UnitMWh Unit = "MWh"
+	UnitGWh Unit = "GWh"
+	UnitKG  Unit = "KG"
+	UnitLB  Unit = "LB"
+)
+
+// Dimension is the physical dimension measured by a Unit.
+type Dimension int
+
+const (
+	DimensionUnknown Dimension = iota
+	DimensionPower
+	DimensionEnergy
+	DimensionMass
+)
+
+type unitInfo struct {
+	base      Unit
+	factor    float64
+	dimension Dimension
+}
+
+// units maps the (upper case) name of a unit to its base unit and conversion factor.
+var units = map[string]unitInfo{
+	"W":   {base: UnitW, factor: 1, dimension: DimensionPower},
+	"KW":  {base: UnitW, factor: 1e3, dimension: DimensionPower},
+	"MW":  {base: UnitW, factor: 1e6, dimension: DimensionPower},
+	"GW":  {base: UnitW, factor: 1e9, dimension: DimensionPower},
+	"WH":  {base: UnitWh, factor: 1, dimension: DimensionEnergy},
+	"KWH": {base: UnitWh, factor: 1e3, dimension: DimensionEnergy},
+	"MWH": {base: UnitWh, factor: 1e6, dimension: DimensionEnergy},
+	"GWH": {base: UnitWh, factor: 1e9, dimension: DimensionEnergy},
+	"KG":  {base: UnitKG, factor: 1, dimension: DimensionMass},
+	"LB":  {base: UnitKG, factor: 0.45359237, dimension: DimensionMass},
+}
+
+func (u Unit) info() (unitInfo, bool) {
+	info, ok := units[strings.ToUpper(string(u))]
+	return info, ok
+}
+
+// Dimension returns the physical dimension measured by the unit. Returns DimensionUnknown if the unit is not recognized.
+func (u Unit) Dimension() Dimension {
+	info, _ := u.info()
+	return info.dimension
+}
+
+// Base returns the unit that values in this unit are normalized to: W for power, Wh for energy and KG for mass.
+// Unrecognized units are returned as-is.
+func (u Unit) Base() Unit {
+	if info, ok := u.info(); ok {
+		return info.base
+	}
+	return u
+}
+
+// Quantity is a value with its unit of measurement.
+type Quantity struct {
+	Unit  Unit
+	Value float64
+}
+
+// Convert returns the quantity, expressed in the specified unit. Returns an error if either unit is not recognized,
+// or if the units measure a different dimension (e.g. power vs. energy).
+func (q Quantity) Convert(to Unit) (Quantity, error) {
+	from, ok := q.Unit.info()
+	if !ok {
+		return q, fmt.Errorf("unknown unit %q", q.Unit)
+	}
+	target, ok := to.info()
+	if !ok {
+		return q, fmt.Errorf("unknown unit %q", to)
+	}
+	if from.dimension != target.dimension {
+		return q, fmt.Errorf("cannot convert %q to %q", q.Unit, to)
+	}
+	return Quantity{Value: q.Value * from.factor / target.factor, Unit: to}, nil
+}
+
+// Normalize returns the quantity, expressed in its base unit. Quantities with an unrecognized unit are returned as-is.
+func (q Quantity) Normalize() Quantity {
+	if info, ok := q.Unit.info(); ok {
+		return Quantity{Value: q.Value * info.factor, Unit: info.base}
+	}
+	return q
+}
+
+func (q Quantity) String() string {
+	return fmt.Sprintf("%g %s", q.Value, q.Unit)
+}
+
+// unitNormalizer is implemented by responses that report values in a unit of measurement.
+// When the Client was created WithNormalizeUnits, call invokes normalizeUnits after decoding the response.
+type unitNormalizer interface {
+	normalizeUnits()
+}
+
+// normalizeValue converts the value to the base unit of the unit pointed to by u.
+// Returns the conversion factor, so callers can apply it to related values.
+func normalizeValue(u *Unit, values ...*float64) float64 {
+	info, ok := u.info()
+	if !ok {
+		return 1
+	}
+	for _, v := range values {
+		*v *= info.factor
+	}
+	*u = info.base
+	return info.factor
+}
+
+func normalizeValues(u *Unit, values []Value) {
+	factor := normalizeValue(u)
+	for i := range values {
+		values[i].Value *= factor
+	}
+}
+
+func (r *GetEnergyMeasurementsResponse) normalizeUnits() {
+	normalizeValues(&r.Energy.Unit, r.Energy.Values)
+}
+
+func (r *GetEnergyForTimeframeResponse) normalizeUnits() {
+	normalizeValue(&r.TimeFrameEnergy.Unit, &r.TimeFrameEnergy.Energy)
+	normalizeValue(&r.TimeFrameEnergy.StartLifetimeEnergy.Unit, &r.TimeFrameEnergy.StartLifetimeEnergy.Energy)
+	normalizeValue(&r.TimeFrameEnergy.EndLifetimeEnergy.Unit, &r.TimeFrameEnergy.EndLifetimeEnergy.Energy)
+}
+
+func (r *GetPowerMeasurementsResponse) normalizeUnits() {
+	normalizeValues(&r.Power.Unit, r.Power.Values)
+}
+
+func (r *GetPowerDetailsResponse) normalizeUnits() {
+	unit := r.PowerDetails.Unit
+	for i := range r.PowerDetails.Meters {
+		u := unit
+		normalizeValues(&u, r.PowerDetails.Meters[i].Values)
+	}
+	r.PowerDetails.Unit = unit.Base()
+}
+
+func (r *GetEnergyDetailsResponse) normalizeUnits() {
+	unit := r.EnergyDetails.Unit
+	for i := range r.EnergyDetails.Meters {
+		u := unit
+		normalizeValues(&u, r.EnergyDetails.Meters[i].Values)
+	}
+	r.EnergyDetails.Unit = unit.Base()
+}
+
+func (r *GetPowerFlowResponse) normalizeUnits() {
+	f := &r.CurrentPowerFlow
+	normalizeValue(&f.Unit, &f.Grid.CurrentPower, &f.Load.CurrentPower, &f.PV.CurrentPower, &f.Storage.CurrentPower)
+}
+
+func (r *GetEnvBenefitsResponse) normalizeUnits() {
+	g := &r.EnvBenefits.GasEmissionSaved
+	normalizeValue(&g.Units, &g.Co2, &g.Nox, &g.So2)
+}
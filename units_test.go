@@ -0,0 +1,122 @@
+package solaredge
+
+import (
+	"codeberg.org/clambin/go-common/testutils"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQuantity_Convert(t *testing.T) {
+	tests := []struct {
+		name    string
+		q       Quantity
+		to      Unit
+		want    Quantity
+		wantErr bool
+	}{
+		{name: "kW to W", q: Quantity{Value: 1.5, Unit: UnitKW}, to: UnitW, want: Quantity{Value: 1500, Unit: UnitW}},
+		{name: "Wh to kWh", q: Quantity{Value: 1500, Unit: UnitWh}, to: UnitKWh, want: Quantity{Value: 1.5, Unit: UnitKWh}},
+		{name: "case insensitive", q: Quantity{Value: 2, Unit: "KWH"}, to: UnitWh, want: Quantity{Value: 2000, Unit: UnitWh}},
+		{name: "LB to KG", q: Quantity{Value: 100, Unit: UnitLB}, to: UnitKG, want: Quantity{Value: 45.359237, Unit: UnitKG}},
+		{name: "power to energy", q: Quantity{Value: 1, Unit: UnitKW}, to: UnitWh, wantErr: true},
+		{name: "unknown source unit", q: Quantity{Value: 1, Unit: "foo"}, to: UnitW, wantErr: true},
+		{name: "unknown target unit", q: Quantity{Value: 1, Unit: UnitW}, to: "foo", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.q.Convert(tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Convert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("Convert() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuantity_Normalize(t *testing.T) {
+	tests := []struct {
+		name string
+		q    Quantity
+		want Quantity
+	}{
+		{name: "power", q: Quantity{Value: 2, Unit: UnitMW}, want: Quantity{Value: 2e6, Unit: UnitW}},
+		{name: "energy", q: Quantity{Value: 2, Unit: UnitKWh}, want: Quantity{Value: 2000, Unit: UnitWh}},
+		{name: "mass", q: Quantity{Value: 2, Unit: UnitKG}, want: Quantity{Value: 2, Unit: UnitKG}},
+		{name: "unknown", q: Quantity{Value: 2, Unit: "foo"}, want: Quantity{Value: 2, Unit: "foo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.Normalize(); got != tt.want {
+				t.Errorf("Normalize() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_NormalizeUnits(t *testing.T) {
+	s := httptest.NewServer(&testutils.TestServer{Responses: testutils.Responses{
+		"/site/1/currentPowerFlow": {http.MethodGet: {Body: GetPowerFlowResponse{CurrentPowerFlow: PowerFlow{
+			Unit: UnitKW,
+			Grid: PowerFlowReading{CurrentPower: 1.5},
+			PV:   PowerFlowReading{CurrentPower: 0.5},
+		}}}},
+		"/site/1/energyDetails": {http.MethodGet: {Body: GetEnergyDetailsResponse{EnergyDetails: EnergyDetails{
+			Unit:   UnitKWh,
+			Meters: []MeterReadings{{Type: "Production", Values: []Value{{Value: 1}, {Value: 2}}}},
+		}}}},
+		"/site/1/envBenefits": {http.MethodGet: {Body: GetEnvBenefitsResponse{EnvBenefits: EnvBenefits{
+			GasEmissionSaved: struct {
+				Units Unit    `json:"units"`
+				Co2   float64 `json:"co2"`
+				Nox   float64 `json:"nox"`
+				So2   float64 `json:"so2"`
+			}{Units: UnitLB, Co2: 1000},
+		}}}},
+	}})
+	defer s.Close()
+
+	ctx := context.Background()
+	c, err := NewClient(validKey, WithBaseURL(s.URL), WithNormalizeUnits())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flow, err := c.GetPowerFlow(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := flow.CurrentPowerFlow; got.Unit != UnitW || got.Grid.CurrentPower != 1500 || got.PV.CurrentPower != 500 {
+		t.Errorf("unexpected power flow: %+v", got)
+	}
+
+	energy, err := c.GetEnergyDetails(ctx, 1, TimeUnitDay, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := energy.EnergyDetails; got.Unit != UnitWh || !reflect.DeepEqual(got.Meters[0].Values, []Value{{Value: 1000}, {Value: 2000}}) {
+		t.Errorf("unexpected energy details: %+v", got)
+	}
+
+	benefits, err := c.GetEnvBenefits(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := benefits.EnvBenefits.GasEmissionSaved; got.Units != UnitKG || got.Co2 != 453.59237 {
+		t.Errorf("unexpected env benefits: %+v", got)
+	}
+
+	c.normalizeUnits = false
+	flow, err = c.GetPowerFlow(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := flow.CurrentPowerFlow; got.Unit != UnitKW || got.Grid.CurrentPower != 1.5 {
+		t.Errorf("unexpected power flow: %+v", got)
+	}
+}
@@ -0,0 +1,207 @@
+package solaredge
+
+import (
+	"cmp"
+	"context"
+	"math/rand/v2"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Endpoint selects the API endpoints polled by a Watcher.
+type Endpoint int
+
+const (
+	// EndpointOverview polls GetPowerOverview.
+	EndpointOverview Endpoint = 1 << iota
+	// EndpointPowerFlow polls GetPowerFlow.
+	EndpointPowerFlow
+)
+
+func (e Endpoint) count() int {
+	var n int
+	for _, endpoint := range []Endpoint{EndpointOverview, EndpointPowerFlow} {
+		if e&endpoint != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// An Event is emitted by a Watcher. It is one of OverviewEvent, PowerFlowEvent or ErrorEvent.
+type Event interface {
+	// Site returns the ID of the site that emitted the event.
+	Site() int
+}
+
+// OverviewEvent is emitted when a site's power overview has been updated.
+type OverviewEvent struct {
+	Overview PowerOverview
+	SiteID   int
+}
+
+func (e OverviewEvent) Site() int { return e.SiteID }
+
+// PowerFlowEvent is emitted when a site's current power flow has changed.
+type PowerFlowEvent struct {
+	PowerFlow PowerFlow
+	SiteID    int
+}
+
+func (e PowerFlowEvent) Site() int { return e.SiteID }
+
+// ErrorEvent is emitted when polling a site failed.
+type ErrorEvent struct {
+	Err      error
+	Endpoint Endpoint
+	SiteID   int
+}
+
+func (e ErrorEvent) Site() int { return e.SiteID }
+
+// A Watcher polls the power overview and/or the power flow of one or more sites, and emits an Event whenever one changes.
+//
+// Polling intervals are chosen so the Watcher stays within the daily quota of the API key: with the default quota of
+// 300 requests per day, watching the overview of one site results in a poll every ~5 minutes. Each site is polled
+// independently, with jitter applied to avoid polling all sites at the same time.
+type Watcher struct {
+	// Client performs the calls. This is typically a *Client or a *Pool.
+	Client API
+	// Sites to watch.
+	Sites []int
+	// Endpoints to poll. Defaults to EndpointOverview.
+	Endpoints Endpoint
+	// DailyQuota is the number of requests per day the Watcher may use. Zero or negative values select
+	// DefaultDailyQuota. Lower this value if the API key is also used by other applications.
+	DailyQuota int
+	// MinInterval is the shortest interval between two polls of the same site. Zero or negative values select the
+	// default of one minute.
+	MinInterval time.Duration
+	// Jitter is the maximum relative deviation applied to each interval. Defaults to 0.1 (i.e. ±10%).
+	// Set to NoJitter to poll at a fixed interval. Values above 1 are treated as 1.
+	Jitter float64
+}
+
+// NoJitter disables the jitter of a Watcher's polling interval.
+const NoJitter = -1
+
+const (
+	defaultMinInterval = time.Minute
+	defaultJitter      = 0.1
+)
+
+// Interval returns the average interval between two polls of a site.
+func (w *Watcher) Interval() time.Duration {
+	quota := w.DailyQuota
+	if quota <= 0 {
+		quota = DefaultDailyQuota
+	}
+	minInterval := w.MinInterval
+	if minInterval <= 0 {
+		minInterval = defaultMinInterval
+	}
+	requestsPerPoll := len(w.Sites) * w.endpoints().count()
+	return max(24*time.Hour*time.Duration(requestsPerPoll)/time.Duration(quota), minInterval)
+}
+
+func (w *Watcher) endpoints() Endpoint {
+	return cmp.Or(w.Endpoints, EndpointOverview)
+}
+
+// Run polls all sites until the context is cancelled, calling handler for each Event. Calls to handler are serialized.
+func (w *Watcher) Run(ctx context.Context, handler func(Event)) {
+	var lock sync.Mutex
+	emit := func(e Event) {
+		lock.Lock()
+		defer lock.Unlock()
+		handler(e)
+	}
+
+	interval := w.Interval()
+	var wg sync.WaitGroup
+	for _, id := range w.Sites {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			w.watchSite(ctx, id, interval, emit)
+		}(id)
+	}
+	wg.Wait()
+}
+
+// Events polls all sites until the context is cancelled and returns the Events on a channel.
+// The channel is closed when the context is cancelled.
+func (w *Watcher) Events(ctx context.Context) <-chan Event {
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		w.Run(ctx, func(e Event) {
+			select {
+			case ch <- e:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return ch
+}
+
+func (w *Watcher) watchSite(ctx context.Context, id int, interval time.Duration, emit func(Event)) {
+	// spread the first poll of each site over the interval
+	delay := time.Duration(rand.Int64N(int64(interval)))
+	var last siteState
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		last = w.poll(ctx, id, last, emit)
+		delay = w.jitter(interval)
+	}
+}
+
+func (w *Watcher) jitter(interval time.Duration) time.Duration {
+	var jitter float64
+	switch {
+	case w.Jitter == 0:
+		jitter = defaultJitter
+	case w.Jitter > 0:
+		jitter = min(w.Jitter, 1)
+	}
+	return time.Duration(float64(interval) * (1 + jitter*(2*rand.Float64()-1)))
+}
+
+type siteState struct {
+	overview  *PowerOverview
+	powerFlow *PowerFlow
+}
+
+func (w *Watcher) poll(ctx context.Context, id int, last siteState, emit func(Event)) siteState {
+	endpoints := w.endpoints()
+	if endpoints&EndpointOverview != 0 {
+		resp, err := w.Client.GetPowerOverview(ctx, id)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				emit(ErrorEvent{SiteID: id, Endpoint: EndpointOverview, Err: err})
+			}
+		case last.overview == nil || !time.Time(resp.Overview.LastUpdateTime).Equal(time.Time(last.overview.LastUpdateTime)):
+			last.overview = &resp.Overview
+			emit(OverviewEvent{SiteID: id, Overview: resp.Overview})
+		}
+	}
+	if endpoints&EndpointPowerFlow != 0 {
+		resp, err := w.Client.GetPowerFlow(ctx, id)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				emit(ErrorEvent{SiteID: id, Endpoint: EndpointPowerFlow, Err: err})
+			}
+		case last.powerFlow == nil || !reflect.DeepEqual(resp.CurrentPowerFlow, *last.powerFlow):
+			last.powerFlow = &resp.CurrentPowerFlow
+			emit(PowerFlowEvent{SiteID: id, PowerFlow: resp.CurrentPowerFlow})
+		}
+	}
+	return last
+}
@@ -0,0 +1,98 @@
+package solaredge
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Interval(t *testing.T) {
+	tests := []struct {
+		name    string
+		watcher Watcher
+		want    time.Duration
+	}{
+		{name: "defaults", watcher: Watcher{Sites: []int{1}}, want: 4*time.Minute + 48*time.Second},
+		{name: "all endpoints", watcher: Watcher{Sites: []int{1, 2}, Endpoints: EndpointOverview | EndpointPowerFlow}, want: 4 * 4 * (time.Minute + 12*time.Second)},
+		{name: "custom quota", watcher: Watcher{Sites: []int{1}, DailyQuota: 1440}, want: time.Minute},
+		{name: "minimum interval", watcher: Watcher{Sites: []int{1}, DailyQuota: 100000, MinInterval: 30 * time.Second}, want: 30 * time.Second},
+		{name: "negative minimum interval", watcher: Watcher{Sites: []int{1}, DailyQuota: 100000, MinInterval: -time.Second}, want: time.Minute},
+		{name: "negative quota", watcher: Watcher{Sites: []int{1}, DailyQuota: -1}, want: 4*time.Minute + 48*time.Second},
+		{name: "no sites", watcher: Watcher{}, want: time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.watcher.Interval(); got != tt.want {
+				t.Errorf("Interval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatcher_jitter(t *testing.T) {
+	const interval = time.Minute
+	tests := []struct {
+		name     string
+		jitter   float64
+		min, max time.Duration
+	}{
+		{name: "default", jitter: 0, min: 54 * time.Second, max: 66 * time.Second},
+		{name: "custom", jitter: 0.5, min: 30 * time.Second, max: 90 * time.Second},
+		{name: "disabled", jitter: NoJitter, min: interval, max: interval},
+		{name: "clamped", jitter: 5, min: 0, max: 2 * interval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := Watcher{Jitter: tt.jitter}
+			for range 1000 {
+				if got := w.jitter(interval); got < tt.min || got > tt.max {
+					t.Fatalf("jitter() = %v, want [%v, %v]", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestWatcher_Events(t *testing.T) {
+	w := Watcher{
+		Client:      &Client{baseURL: testServer.URL, HTTPClient: http.DefaultClient},
+		Sites:       []int{1, 2},
+		Endpoints:   EndpointOverview | EndpointPowerFlow,
+		DailyQuota:  1e9,
+		MinInterval: 10 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+
+	counts := make(map[string]int)
+	for e := range w.Events(ctx) {
+		switch e := e.(type) {
+		case OverviewEvent:
+			counts["overview"]++
+			if e.Site() != 1 || e.Overview.CurrentPower.Power != 200 {
+				t.Errorf("unexpected event: %+v", e)
+			}
+		case PowerFlowEvent:
+			counts["powerflow"]++
+			if e.Site() != 1 || e.PowerFlow.Grid.CurrentPower != 100 {
+				t.Errorf("unexpected event: %+v", e)
+			}
+		case ErrorEvent:
+			counts["error"]++
+			if e.Site() != 2 || e.Err == nil {
+				t.Errorf("unexpected event: %+v", e)
+			}
+		}
+	}
+
+	// site 1 doesn't change: only the first poll results in an event
+	if counts["overview"] != 1 || counts["powerflow"] != 1 {
+		t.Errorf("unexpected events: %v", counts)
+	}
+	// site 2 doesn't exist: each poll fails
+	if counts["error"] < 2 {
+		t.Errorf("expected multiple errors, got %d", counts["error"])
+	}
+}